@@ -13,6 +13,7 @@ import (
 type AccountService struct {
 	accessToken string
 	sandbox     bool
+	domain      string
 
 	err error
 }
@@ -122,6 +123,7 @@ func (a *AccountService) List() ([]*AccountResp, error) {
 		Url:         "https://b2b.revolut.com/api/1.0/accounts",
 		AccessToken: a.accessToken,
 		Sandbox:     a.sandbox,
+		Domain:      a.domain,
 		Body:        nil,
 	})
 	if err != nil {
@@ -152,6 +154,7 @@ func (a *AccountService) WithId(id string) (*AccountResp, error) {
 		Url:         fmt.Sprintf("https://b2b.revolut.com/api/1.0/accounts/%s", id),
 		AccessToken: a.accessToken,
 		Sandbox:     a.sandbox,
+		Domain:      a.domain,
 		Body:        nil,
 	})
 	if err != nil {
@@ -181,6 +184,7 @@ func (a *AccountService) DetailWithId(id string) ([]*AccountDetailResp, error) {
 		Url:         fmt.Sprintf("https://b2b.revolut.com/api/1.0/accounts/%s/bank-details", id),
 		AccessToken: a.accessToken,
 		Sandbox:     a.sandbox,
+		Domain:      a.domain,
 		Body:        nil,
 	})
 	if err != nil {
@@ -0,0 +1,99 @@
+package business
+
+import (
+	"time"
+)
+
+// API is the interface of Client, code depending on it can be tested with businesstest.MockClient.
+type API interface {
+	Account() AccountAPI
+	Counterparty() CounterpartyAPI
+	Transfer() TransferAPI
+	Payment() PaymentAPI
+	PaymentDraft() PaymentDraftAPI
+	Exchange() ExchangeAPI
+	Webhook() WebhookAPI
+	WebhookV2() WebhookV2API
+}
+
+// OAuthAPI is the interface of OAuthService.
+type OAuthAPI interface {
+	ExchangeAuthorisationCode(code string) (*OAuthResp, error)
+	RefreshAccessToken(refreshToken string) (*OAuthResp, error)
+	GetAuthorisationCode(clientId, redirectUri string) ([]*AuthorizationCodeResp, error)
+}
+
+// AccountAPI is the interface of AccountService.
+type AccountAPI interface {
+	List() ([]*AccountResp, error)
+	WithId(id string) (*AccountResp, error)
+	DetailWithId(id string) ([]*AccountDetailResp, error)
+}
+
+// CounterpartyAPI is the interface of CounterpartyService.
+type CounterpartyAPI interface {
+	AddRevolut(revolutCounterparty *RevolutCounterpartyReq) (*CounterpartyResp, error)
+	AddNonRevolut(nonRevolutCounterparty *NonRevolutCounterpartyReq) (*CounterpartyResp, error)
+	Delete(id string) error
+	WithId(id string) (*CounterpartyResp, error)
+	List() ([]*CounterpartyResp, error)
+}
+
+// TransferAPI is the interface of TransferService.
+type TransferAPI interface {
+	Create(transferReq *TransferReq) (*TransferResp, error)
+}
+
+// PaymentAPI is the interface of PaymentService.
+type PaymentAPI interface {
+	Create(paymentReq *PaymentReq) (*TransactionResp, error)
+	WithId(id string) (*TransactionResp, error)
+	WithRequestId(requestId string) (*TransactionResp, error)
+	Cancel(id string) error
+	CancelTransaction(transaction *TransactionResp) error
+	List(transactionReq *TransactionReq) ([]*TransactionResp, error)
+}
+
+// PaymentDraftAPI is the interface of PaymentDraftService.
+type PaymentDraftAPI interface {
+	Create(paymentDraftReq *PaymentDraftReq) (*PaymentDraftResp, error)
+	List() (*PaymentDrafts, error)
+	WithId(id string) (*PaymentDraftDetailPayment, error)
+	Delete(id string) error
+}
+
+// ExchangeAPI is the interface of ExchangeService.
+type ExchangeAPI interface {
+	Rate(exchangeRateReq *ExchangeRateReq) (*ExchangeRateResp, error)
+	Exchange(exchangeReq *ExchangeReq) (*ExchangeResp, error)
+}
+
+// WebhookAPI is the interface of WebhookService.
+type WebhookAPI interface {
+	Set(url string) error
+	Delete() error
+}
+
+// WebhookV2API is the interface of WebhookV2Service.
+type WebhookV2API interface {
+	Create(webhookReq *WebhookV2Req) (*WebhookV2Resp, error)
+	List() ([]*WebhookV2Resp, error)
+	WithId(id string) (*WebhookV2Resp, error)
+	Update(id string, webhookReq *WebhookV2Req) (*WebhookV2Resp, error)
+	Delete(id string) error
+	RotateSigningSecret(id string, expirationPeriod time.Duration) (*WebhookV2Resp, error)
+	FailedEvents(id string, failedEventReq *FailedWebhookEventReq) ([]*FailedWebhookEvent, error)
+}
+
+var (
+	_ API             = (*Client)(nil)
+	_ OAuthAPI        = (*OAuthService)(nil)
+	_ AccountAPI      = (*AccountService)(nil)
+	_ CounterpartyAPI = (*CounterpartyService)(nil)
+	_ TransferAPI     = (*TransferService)(nil)
+	_ PaymentAPI      = (*PaymentService)(nil)
+	_ PaymentDraftAPI = (*PaymentDraftService)(nil)
+	_ ExchangeAPI     = (*ExchangeService)(nil)
+	_ WebhookAPI      = (*WebhookService)(nil)
+	_ WebhookV2API    = (*WebhookV2Service)(nil)
+)
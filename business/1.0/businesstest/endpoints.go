@@ -0,0 +1,753 @@
+package businesstest
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	business "github.com/adless-tech/go-revolut/business/1.0"
+	"github.com/dgrijalva/jwt-go"
+)
+
+const clientAssertionType = "urn:ietf:params:oauth:client-assertion-type:jwt-bearer"
+
+func (s *Server) token(w http.ResponseWriter, r *http.Request) {
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		writeJson(w, http.StatusBadRequest, map[string]string{"error": "invalid_request"})
+		return
+	}
+	form, err := url.ParseQuery(string(body))
+	if err != nil {
+		writeJson(w, http.StatusBadRequest, map[string]string{"error": "invalid_request"})
+		return
+	}
+
+	claims := jwt.MapClaims{}
+	if form.Get("client_assertion_type") != clientAssertionType {
+		writeJson(w, http.StatusBadRequest, map[string]string{"error": "invalid_client", "error_description": "unsupported client_assertion_type"})
+		return
+	}
+	if _, _, err := new(jwt.Parser).ParseUnverified(form.Get("client_assertion"), claims); err != nil || claims["sub"] != form.Get("client_id") {
+		writeJson(w, http.StatusBadRequest, map[string]string{"error": "invalid_client", "error_description": "invalid client_assertion"})
+		return
+	}
+
+	resp := &business.OAuthResp{TokenType: "bearer"}
+	switch form.Get("grant_type") {
+	case "refresh_token":
+		if form.Get("refresh_token") != s.RefreshToken {
+			writeJson(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant", "error_description": "invalid refresh_token"})
+			return
+		}
+	case "authorization_code":
+		if form.Get("code") != s.AuthorisationCode {
+			writeJson(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant", "error_description": "invalid code"})
+			return
+		}
+		resp.RefreshToken = s.RefreshToken
+	default:
+		writeJson(w, http.StatusBadRequest, map[string]string{"error": "unsupported_grant_type"})
+		return
+	}
+
+	ttl := s.TokenTTL
+	if ttl <= 0 {
+		ttl = 40 * time.Minute
+	}
+	resp.AccessToken = "oa_test_" + randomHex(16)
+	resp.ExpiresIn = int32(ttl / time.Second)
+
+	s.mu.Lock()
+	s.tokens[resp.AccessToken] = time.Now().Add(ttl)
+	s.mu.Unlock()
+
+	writeJson(w, http.StatusOK, resp)
+}
+
+func (s *Server) accountsEndpoint(w http.ResponseWriter, r *http.Request, rest []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(rest) == 0 {
+		r := make([]*business.AccountResp, 0, len(s.accounts))
+		for _, account := range s.accounts {
+			c := *account
+			r = append(r, &c)
+		}
+		writeJson(w, http.StatusOK, r)
+		return
+	}
+
+	account := s.account(rest[0])
+	switch {
+	case account == nil:
+		writeError(w, http.StatusNotFound, 3000, "Account not found")
+	case len(rest) == 1:
+		writeJson(w, http.StatusOK, account)
+	case len(rest) == 2 && rest[1] == "bank-details":
+		writeJson(w, http.StatusOK, s.bankDetails[account.Id])
+	default:
+		writeError(w, http.StatusNotFound, 3000, "Not found")
+	}
+}
+
+func (s *Server) addCounterparty(w http.ResponseWriter, r *http.Request) {
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, 3000, err.Error())
+		return
+	}
+	revolut := &business.RevolutCounterpartyReq{}
+	external := &business.NonRevolutCounterpartyReq{}
+	if err := json.Unmarshal(body, revolut); err != nil {
+		writeError(w, http.StatusBadRequest, 3000, err.Error())
+		return
+	}
+	if err := json.Unmarshal(body, external); err != nil {
+		writeError(w, http.StatusBadRequest, 3000, err.Error())
+		return
+	}
+
+	now := time.Now().UTC()
+	counterparty := &business.CounterpartyResp{
+		Id:        newId(),
+		State:     business.CounterpartyState_ACTIVE,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	if revolut.ProfileType != "" {
+		if revolut.Name == "" || (revolut.Phone == "" && revolut.Email == "") {
+			writeError(w, http.StatusUnprocessableEntity, 3000, "name and phone or email are required")
+			return
+		}
+		counterparty.Name = revolut.Name
+		counterparty.Phone = revolut.Phone
+		counterparty.ProfileType = revolut.ProfileType
+		for _, currency := range []string{"GBP", "EUR", "USD"} {
+			counterparty.Accounts = append(counterparty.Accounts, business.CounterpartyRespAccount{
+				Id:       newId(),
+				Currency: currency,
+				Type:     string(business.CounterpartyType_REVOLUT),
+				Name:     revolut.Name,
+				Email:    revolut.Email,
+			})
+		}
+	} else {
+		name := external.CompanyName
+		if name == "" {
+			name = external.InvidualName.FirstName + " " + external.InvidualName.LastName
+		}
+		if name == " " || external.Currency == "" || external.BankCountry == "" || external.AccountNo == "" {
+			writeError(w, http.StatusUnprocessableEntity, 3000, "name, currency, bank_country and account_no are required")
+			return
+		}
+		counterparty.Name = name
+		counterparty.Phone = external.Phone
+		counterparty.Country = external.BankCountry
+		counterparty.Accounts = []business.CounterpartyRespAccount{{
+			Id:               newId(),
+			Currency:         external.Currency,
+			Type:             string(business.CounterpartyType_EXTERNAL),
+			AccountNo:        external.AccountNo,
+			SortCode:         external.SortCode,
+			RoutingNumber:    external.RoutingNumber,
+			Email:            external.Email,
+			Name:             name,
+			BankCountry:      external.BankCountry,
+			RecipientCharges: business.CounterpartyRecipientCharges_NO,
+		}}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, c := range s.counterparties {
+		if c.State == business.CounterpartyState_ACTIVE && c.Name == counterparty.Name && c.ProfileType == counterparty.ProfileType {
+			writeError(w, http.StatusUnprocessableEntity, 3000, "Counterparty already exists")
+			return
+		}
+	}
+	s.counterparties = append(s.counterparties, counterparty)
+
+	writeJson(w, http.StatusOK, counterparty)
+}
+
+func (s *Server) counterparty(w http.ResponseWriter, r *http.Request, rest []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var counterparty *business.CounterpartyResp
+	if len(rest) == 1 {
+		counterparty = s.activeCounterparty(rest[0])
+	}
+	if counterparty == nil {
+		writeError(w, http.StatusNotFound, 3000, "Counterparty not found")
+		return
+	}
+
+	if r.Method == http.MethodDelete {
+		counterparty.State = business.CounterpartyState_INACTIVE
+		counterparty.UpdatedAt = time.Now().UTC()
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	writeJson(w, http.StatusOK, counterparty)
+}
+
+func (s *Server) counterpartiesEndpoint(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	resp := []*business.CounterpartyResp{}
+	for _, counterparty := range s.counterparties {
+		if counterparty.State == business.CounterpartyState_ACTIVE {
+			resp = append(resp, counterparty)
+		}
+	}
+
+	writeJson(w, http.StatusOK, resp)
+}
+
+func (s *Server) activeCounterparty(id string) *business.CounterpartyResp {
+	for _, counterparty := range s.counterparties {
+		if counterparty.Id == id && counterparty.State == business.CounterpartyState_ACTIVE {
+			return counterparty
+		}
+	}
+	return nil
+}
+
+func (s *Server) pay(w http.ResponseWriter, r *http.Request) []interface{} {
+	req := &business.PaymentReq{}
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		writeError(w, http.StatusBadRequest, 3000, err.Error())
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if req.RequestId == "" {
+		writeError(w, http.StatusBadRequest, 3000, "request_id is required")
+		return nil
+	}
+	if tx := s.transactionWithRequestId(req.RequestId); tx != nil {
+		writeJson(w, http.StatusOK, tx)
+		return nil
+	}
+
+	account := s.account(req.AccountId)
+	if account == nil {
+		writeError(w, http.StatusNotFound, 3000, "Account not found")
+		return nil
+	}
+	counterparty := s.activeCounterparty(req.Receiver.CounterpartyId)
+	if counterparty == nil {
+		writeError(w, http.StatusNotFound, 3000, "Counterparty not found")
+		return nil
+	}
+	var target *business.CounterpartyRespAccount
+	for i, a := range counterparty.Accounts {
+		if (req.Receiver.AccountId == "" && a.Currency == req.Currency) || a.Id == req.Receiver.AccountId {
+			target = &counterparty.Accounts[i]
+			break
+		}
+	}
+	if target == nil {
+		writeError(w, http.StatusUnprocessableEntity, 3000, "Counterparty account not found")
+		return nil
+	}
+	if errMsg := s.checkDebit(account, req.Currency, req.Amount); errMsg != "" {
+		writeError(w, http.StatusUnprocessableEntity, 3000, errMsg)
+		return nil
+	}
+	if target.Currency != "" && target.Currency != req.Currency {
+		writeError(w, http.StatusUnprocessableEntity, 3000, "Currency of the counterparty account does not match")
+		return nil
+	}
+
+	state := business.PaymentState_PENDING
+	counterpartyType := business.CounterpartyType_EXTERNAL
+	if counterparty.ProfileType != "" {
+		state = business.PaymentState_COMPLETE
+		counterpartyType = business.CounterpartyType_REVOLUT
+	}
+	if req.ScheduleFor != "" {
+		state = business.PaymentState_CREATED
+	}
+
+	tx := s.newTransaction(business.PaymentType_TRANSFER, req.RequestId, req.Reference, state)
+	tx.ScheduledFor = req.ScheduleFor
+	tx.Legs = []business.TransactionLeg{s.debit(account, req.Amount, "To "+counterparty.Name)}
+	tx.Legs[0].Counterparty = business.LegCounterparty{
+		Id:        counterparty.Id,
+		Type:      counterpartyType,
+		AccountId: target.Id,
+	}
+
+	writeJson(w, http.StatusOK, tx)
+	return []interface{}{s.createdEvent(tx)}
+}
+
+func (s *Server) transactionEndpoint(w http.ResponseWriter, r *http.Request, rest []string) []interface{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var tx *business.TransactionResp
+	if len(rest) == 1 {
+		if r.URL.Query().Get("id_type") == "request_id" {
+			tx = s.transactionWithRequestId(rest[0])
+		} else {
+			tx = s.transaction(rest[0])
+		}
+	}
+	if tx == nil {
+		writeError(w, http.StatusNotFound, 3000, "Transaction not found")
+		return nil
+	}
+
+	if r.Method == http.MethodDelete {
+		if !tx.State.CanCancel() {
+			writeError(w, http.StatusUnprocessableEntity, 3000, "Transaction in state "+string(tx.State)+" cannot be cancelled")
+			return nil
+		}
+		old := s.setState(tx, business.PaymentState_REVERTED, "cancelled")
+		w.WriteHeader(http.StatusNoContent)
+		return s.stateChangedEvents(tx, old)
+	}
+
+	writeJson(w, http.StatusOK, tx)
+	return nil
+}
+
+func (s *Server) transactionsEndpoint(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	from, err := parseTime(query.Get("from"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, 3000, "invalid from")
+		return
+	}
+	to, err := parseTime(query.Get("to"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, 3000, "invalid to")
+		return
+	}
+	count := 100
+	if c := query.Get("count"); c != "" {
+		if count, err = strconv.Atoi(c); err != nil || count < 1 || count > 1000 {
+			writeError(w, http.StatusBadRequest, 3000, "invalid count")
+			return
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	resp := []*business.TransactionResp{}
+	for _, tx := range s.transactions {
+		if !from.IsZero() && tx.CreatedAt.Before(from) {
+			continue
+		}
+		if !to.IsZero() && !tx.CreatedAt.Before(to) {
+			continue
+		}
+		if t := query.Get("type"); t != "" && string(tx.Type) != t {
+			continue
+		}
+		if c := query.Get("counterparty"); c != "" && (len(tx.Legs) == 0 || tx.Legs[0].Counterparty.Id != c) {
+			continue
+		}
+		resp = append(resp, tx)
+	}
+	sortTransactions(resp)
+	if len(resp) > count {
+		resp = resp[:count]
+	}
+
+	writeJson(w, http.StatusOK, resp)
+}
+
+func (s *Server) transfer(w http.ResponseWriter, r *http.Request) []interface{} {
+	req := &business.TransferReq{}
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		writeError(w, http.StatusBadRequest, 3000, err.Error())
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if req.RequestId == "" {
+		writeError(w, http.StatusBadRequest, 3000, "request_id is required")
+		return nil
+	}
+	if tx := s.transactionWithRequestId(req.RequestId); tx != nil {
+		writeJson(w, http.StatusOK, &business.TransferResp{Id: tx.Id, State: string(tx.State), CreatedAt: tx.CreatedAt, CompletedAt: tx.CompletedAt})
+		return nil
+	}
+
+	source, target := s.account(req.SourceAccountId), s.account(req.TargetAccountId)
+	if source == nil || target == nil {
+		writeError(w, http.StatusNotFound, 3000, "Account not found")
+		return nil
+	}
+	if source.Id == target.Id || source.Currency != target.Currency {
+		writeError(w, http.StatusUnprocessableEntity, 3000, "Transfer needs two accounts of the same currency")
+		return nil
+	}
+	if errMsg := s.checkDebit(source, req.Currency, req.Amount); errMsg != "" {
+		writeError(w, http.StatusUnprocessableEntity, 3000, errMsg)
+		return nil
+	}
+
+	tx := s.newTransaction(business.PaymentType_TRANSFER, req.RequestId, req.Reference, business.PaymentState_COMPLETE)
+	tx.Legs = []business.TransactionLeg{
+		s.debit(source, req.Amount, "To "+target.Name),
+		s.debit(target, -req.Amount, "From "+source.Name),
+	}
+
+	writeJson(w, http.StatusOK, &business.TransferResp{Id: tx.Id, State: string(tx.State), CreatedAt: tx.CreatedAt, CompletedAt: tx.CompletedAt})
+	return []interface{}{s.createdEvent(tx)}
+}
+
+func (s *Server) rate(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	from, to := query.Get("from"), query.Get("to")
+	amount := 1.0
+	if a := query.Get("amount"); a != "" {
+		var err error
+		if amount, err = strconv.ParseFloat(a, 64); err != nil {
+			writeError(w, http.StatusBadRequest, 3000, "invalid amount")
+			return
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rate, ok := s.rateOf(from, to)
+	if !ok {
+		writeError(w, http.StatusUnprocessableEntity, 3000, "Currency pair "+from+"/"+to+" is not supported")
+		return
+	}
+
+	writeJson(w, http.StatusOK, &business.ExchangeRateResp{
+		From:     business.Amount{Amount: amount, Currency: from},
+		To:       business.Amount{Amount: round(amount * rate), Currency: to},
+		Rate:     rate,
+		Fee:      business.Amount{Amount: round(amount * s.ExchangeFee), Currency: from},
+		RateDate: time.Now().UTC(),
+	})
+}
+
+func (s *Server) exchange(w http.ResponseWriter, r *http.Request) []interface{} {
+	req := &business.ExchangeReq{}
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		writeError(w, http.StatusBadRequest, 3000, err.Error())
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if req.RequestId == "" {
+		writeError(w, http.StatusBadRequest, 3000, "request_id is required")
+		return nil
+	}
+	if tx := s.transactionWithRequestId(req.RequestId); tx != nil {
+		writeJson(w, http.StatusOK, &business.ExchangeResp{Id: tx.Id, State: string(tx.State), CreatedAt: tx.CreatedAt, CompletedAt: tx.CompletedAt})
+		return nil
+	}
+
+	source, target := s.account(req.From.AccountId), s.account(req.To.AccountId)
+	if source == nil || target == nil {
+		writeError(w, http.StatusNotFound, 3000, "Account not found")
+		return nil
+	}
+	if source.Currency != req.From.Currency || target.Currency != req.To.Currency || source.Currency == target.Currency {
+		writeError(w, http.StatusUnprocessableEntity, 3000, "Exchange needs accounts of the given different currencies")
+		return nil
+	}
+	if (req.From.Amount > 0) == (req.To.Amount > 0) {
+		writeError(w, http.StatusUnprocessableEntity, 3000, "Exactly one of from.amount and to.amount is required")
+		return nil
+	}
+	rate, ok := s.rateOf(source.Currency, target.Currency)
+	if !ok || rate == 0 {
+		writeError(w, http.StatusUnprocessableEntity, 3000, "Currency pair "+source.Currency+"/"+target.Currency+" is not supported")
+		return nil
+	}
+
+	fromAmount, toAmount := req.From.Amount, req.To.Amount
+	if fromAmount > 0 {
+		toAmount = round(fromAmount * rate)
+	} else {
+		fromAmount = round(toAmount / rate)
+	}
+	fee := round(fromAmount * s.ExchangeFee)
+	if errMsg := s.checkDebit(source, source.Currency, fromAmount+fee); errMsg != "" {
+		writeError(w, http.StatusUnprocessableEntity, 3000, errMsg)
+		return nil
+	}
+
+	tx := s.newTransaction(business.PaymentType_EXCHANGE, req.RequestId, req.Reference, business.PaymentState_COMPLETE)
+	tx.Legs = []business.TransactionLeg{
+		s.debit(source, fromAmount+fee, "Exchanged to "+target.Currency),
+		s.debit(target, -toAmount, "Exchanged from "+source.Currency),
+	}
+
+	writeJson(w, http.StatusOK, &business.ExchangeResp{Id: tx.Id, State: string(tx.State), CreatedAt: tx.CreatedAt, CompletedAt: tx.CompletedAt})
+	return []interface{}{s.createdEvent(tx)}
+}
+
+func (s *Server) paymentDrafts(w http.ResponseWriter, r *http.Request, rest []string) {
+	var req *business.PaymentDraftReq
+	if r.Method == http.MethodPost {
+		req = &business.PaymentDraftReq{}
+		if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+			writeError(w, http.StatusBadRequest, 3000, err.Error())
+			return
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch {
+	case r.Method == http.MethodPost && len(rest) == 0:
+		if len(req.Payments) == 0 {
+			writeError(w, http.StatusUnprocessableEntity, 3000, "payments are required")
+			return
+		}
+		for _, payment := range req.Payments {
+			if s.account(payment.AccountId) == nil {
+				writeError(w, http.StatusNotFound, 3000, "Account not found")
+				return
+			}
+			if s.activeCounterparty(payment.Receiver.CounterpartyId) == nil {
+				writeError(w, http.StatusNotFound, 3000, "Counterparty not found")
+				return
+			}
+		}
+		d := &draft{id: newId(), req: req}
+		s.drafts = append(s.drafts, d)
+		writeJson(w, http.StatusOK, &business.PaymentDraftResp{Id: d.id})
+
+	case r.Method == http.MethodGet && len(rest) == 0:
+		resp := &business.PaymentDrafts{PaymentOrders: []business.PaymentOrder{}}
+		for _, d := range s.drafts {
+			resp.PaymentOrders = append(resp.PaymentOrders, business.PaymentOrder{
+				Id:            d.id,
+				ScheduledFor:  d.req.ScheduleFor,
+				Title:         d.req.Title,
+				PaymentsCount: len(d.req.Payments),
+			})
+		}
+		writeJson(w, http.StatusOK, resp)
+
+	case len(rest) == 1:
+		i := -1
+		for j, d := range s.drafts {
+			if d.id == rest[0] {
+				i = j
+			}
+		}
+		if i < 0 || r.Method == http.MethodPost {
+			writeError(w, http.StatusNotFound, 3000, "Payment draft not found")
+			return
+		}
+		d := s.drafts[i]
+
+		if r.Method == http.MethodDelete {
+			s.drafts = append(s.drafts[:i], s.drafts[i+1:]...)
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		resp := &business.PaymentDraftDetail{ScheduledFor: d.req.ScheduleFor, Title: d.req.Title}
+		for j, payment := range d.req.Payments {
+			resp.Payments = append(resp.Payments, business.PaymentDraftDetailPayment{
+				Id:        d.id + "-" + strconv.Itoa(j),
+				AccountId: payment.AccountId,
+				Reference: payment.Reference,
+				Receiver:  payment.Receiver,
+				State:     business.PaymentDraftState_CREATED,
+			})
+		}
+		writeJson(w, http.StatusOK, resp)
+
+	default:
+		writeError(w, http.StatusNotFound, 3000, "Not found")
+	}
+}
+
+func (s *Server) webhookV1(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Url string `json:"url"`
+	}
+	if r.Method == http.MethodPost {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Url == "" {
+			writeError(w, http.StatusBadRequest, 3000, "url is required")
+			return
+		}
+	}
+
+	s.mu.Lock()
+	s.webhookUrl = req.Url
+	s.mu.Unlock()
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) webhooksV2(w http.ResponseWriter, r *http.Request, rest []string) {
+	var req *business.WebhookV2Req
+	if r.Method == http.MethodPost && len(rest) == 0 || r.Method == http.MethodPatch {
+		req = &business.WebhookV2Req{}
+		if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+			writeError(w, http.StatusBadRequest, 3000, err.Error())
+			return
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(rest) == 0 {
+		if r.Method == http.MethodPost {
+			if req.Url == "" {
+				writeError(w, http.StatusBadRequest, 3000, "url is required")
+				return
+			}
+			hook := &business.WebhookV2Resp{
+				Id:            newId(),
+				Url:           req.Url,
+				Events:        req.Events,
+				SigningSecret: "wsk_" + randomHex(16),
+			}
+			if len(hook.Events) == 0 {
+				hook.Events = []business.WebhookEvent{business.WebhookEvent_TRANSACTION_CREATED, business.WebhookEvent_TRANSACTION_STATE_CHANGED}
+			}
+			s.webhooks = append(s.webhooks, hook)
+			writeJson(w, http.StatusOK, hook)
+			return
+		}
+
+		resp := []*business.WebhookV2Resp{}
+		for _, hook := range s.webhooks {
+			c := *hook
+			c.SigningSecret = ""
+			resp = append(resp, &c)
+		}
+		writeJson(w, http.StatusOK, resp)
+		return
+	}
+
+	i := -1
+	for j, hook := range s.webhooks {
+		if hook.Id == rest[0] {
+			i = j
+		}
+	}
+	if i < 0 {
+		writeError(w, http.StatusNotFound, 3000, "Webhook not found")
+		return
+	}
+	hook := s.webhooks[i]
+
+	switch {
+	case len(rest) == 1 && r.Method == http.MethodGet:
+		writeJson(w, http.StatusOK, hook)
+	case len(rest) == 1 && r.Method == http.MethodPatch:
+		if req.Url != "" {
+			hook.Url = req.Url
+		}
+		if req.Events != nil {
+			hook.Events = req.Events
+		}
+		writeJson(w, http.StatusOK, hook)
+	case len(rest) == 1 && r.Method == http.MethodDelete:
+		s.webhooks = append(s.webhooks[:i], s.webhooks[i+1:]...)
+		w.WriteHeader(http.StatusNoContent)
+	case len(rest) == 2 && rest[1] == "rotate-signing-secret" && r.Method == http.MethodPost:
+		hook.SigningSecret = "wsk_" + randomHex(16)
+		writeJson(w, http.StatusOK, hook)
+	case len(rest) == 2 && rest[1] == "failed-events" && r.Method == http.MethodGet:
+		writeJson(w, http.StatusOK, []*business.FailedWebhookEvent{})
+	default:
+		writeError(w, http.StatusNotFound, 3000, "Not found")
+	}
+}
+
+// checkDebit returns the error message when the amount cannot be debited from the account.
+func (s *Server) checkDebit(account *business.AccountResp, currency string, amount float64) string {
+	switch {
+	case account.State != business.AccountState_ACTIVE:
+		return "Account is not active"
+	case account.Currency != currency:
+		return "Currency does not match the account"
+	case amount <= 0:
+		return "Amount must be positive"
+	case round(account.Balance-amount) < 0:
+		return "Insufficient balance"
+	}
+	return ""
+}
+
+// debit debits the account and returns the leg, a negative amount credits it.
+func (s *Server) debit(account *business.AccountResp, amount float64, description string) business.TransactionLeg {
+	account.Balance = round(account.Balance - amount)
+	account.UpdatedAt = time.Now().UTC()
+
+	return business.TransactionLeg{
+		LegId:       newId(),
+		AccountId:   account.Id,
+		Amount:      -amount,
+		Currency:    account.Currency,
+		Description: description,
+		Balance:     account.Balance,
+	}
+}
+
+func (s *Server) newTransaction(typ business.PaymentType, requestId, reference string, state business.PaymentState) *business.TransactionResp {
+	now := time.Now().UTC()
+	tx := &business.TransactionResp{
+		Id:        newId(),
+		Type:      typ,
+		RequestId: requestId,
+		State:     state,
+		CreatedAt: now,
+		UpdatedAt: now,
+		Reference: reference,
+	}
+	if state == business.PaymentState_COMPLETE {
+		tx.CompletedAt = now
+	}
+	s.transactions = append(s.transactions, tx)
+
+	return tx
+}
+
+func parseTime(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse("2006-01-02", s); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.RFC3339, s)
+}
+
+func randomHex(n int) string {
+	b := make([]byte, n)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
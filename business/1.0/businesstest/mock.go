@@ -0,0 +1,443 @@
+package businesstest
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	business "github.com/adless-tech/go-revolut/business/1.0"
+)
+
+// ErrNotScripted is returned by a mock method without a scripted response.
+var ErrNotScripted = errors.New("businesstest: no scripted response")
+
+// Call is a call recorded by a mock.
+type Call struct {
+	// the method name, e.g. Create
+	Method string
+	// the arguments of the call
+	Args []interface{}
+}
+
+type response struct {
+	value interface{}
+	err   error
+}
+
+// Mock records the calls of a mock service and returns the responses scripted by Return.
+// The zero value is ready to use.
+type Mock struct {
+	mu        sync.Mutex
+	calls     []Call
+	responses map[string][]response
+}
+
+// Return scripts the response of the next call of the method, value has the type of the
+// first result of the method, nil for the methods returning only an error.
+// The responses of a method queue up, the last one is returned for all the following calls.
+func (m *Mock) Return(method string, value interface{}, err error) *Mock {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.responses == nil {
+		m.responses = map[string][]response{}
+	}
+	m.responses[method] = append(m.responses[method], response{value: value, err: err})
+
+	return m
+}
+
+// Calls returns the recorded calls of the method, all the calls when method is empty.
+func (m *Mock) Calls(method string) []Call {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	calls := []Call{}
+	for _, call := range m.calls {
+		if method == "" || call.Method == method {
+			calls = append(calls, call)
+		}
+	}
+
+	return calls
+}
+
+// Reset forgets the recorded calls and the scripted responses.
+func (m *Mock) Reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.calls = nil
+	m.responses = nil
+}
+
+// called records the call and returns its scripted response.
+func (m *Mock) called(method string, args ...interface{}) (interface{}, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.calls = append(m.calls, Call{Method: method, Args: args})
+
+	queue := m.responses[method]
+	if len(queue) == 0 {
+		return nil, fmt.Errorf("%w for %s", ErrNotScripted, method)
+	}
+	r := queue[0]
+	if len(queue) > 1 {
+		m.responses[method] = queue[1:]
+	}
+
+	return r.value, r.err
+}
+
+// MockClient is a business.API of mock services.
+type MockClient struct {
+	Accounts       *MockAccountService
+	Counterparties *MockCounterpartyService
+	Transfers      *MockTransferService
+	Payments       *MockPaymentService
+	PaymentDrafts  *MockPaymentDraftService
+	Exchanges      *MockExchangeService
+	Webhooks       *MockWebhookService
+	WebhooksV2     *MockWebhookV2Service
+}
+
+func NewMockClient() *MockClient {
+	return &MockClient{
+		Accounts:       &MockAccountService{},
+		Counterparties: &MockCounterpartyService{},
+		Transfers:      &MockTransferService{},
+		Payments:       &MockPaymentService{},
+		PaymentDrafts:  &MockPaymentDraftService{},
+		Exchanges:      &MockExchangeService{},
+		Webhooks:       &MockWebhookService{},
+		WebhooksV2:     &MockWebhookV2Service{},
+	}
+}
+
+func (c *MockClient) Account() business.AccountAPI {
+	return c.Accounts
+}
+
+func (c *MockClient) Counterparty() business.CounterpartyAPI {
+	return c.Counterparties
+}
+
+func (c *MockClient) Transfer() business.TransferAPI {
+	return c.Transfers
+}
+
+func (c *MockClient) Payment() business.PaymentAPI {
+	return c.Payments
+}
+
+func (c *MockClient) PaymentDraft() business.PaymentDraftAPI {
+	return c.PaymentDrafts
+}
+
+func (c *MockClient) Exchange() business.ExchangeAPI {
+	return c.Exchanges
+}
+
+func (c *MockClient) Webhook() business.WebhookAPI {
+	return c.Webhooks
+}
+
+func (c *MockClient) WebhookV2() business.WebhookV2API {
+	return c.WebhooksV2
+}
+
+// MockOAuthService is a business.OAuthAPI returning scripted responses.
+type MockOAuthService struct {
+	Mock
+}
+
+func (m *MockOAuthService) ExchangeAuthorisationCode(code string) (*business.OAuthResp, error) {
+	v, err := m.called("ExchangeAuthorisationCode", code)
+	if v == nil {
+		return nil, err
+	}
+	return v.(*business.OAuthResp), err
+}
+
+func (m *MockOAuthService) RefreshAccessToken(refreshToken string) (*business.OAuthResp, error) {
+	v, err := m.called("RefreshAccessToken", refreshToken)
+	if v == nil {
+		return nil, err
+	}
+	return v.(*business.OAuthResp), err
+}
+
+func (m *MockOAuthService) GetAuthorisationCode(clientId, redirectUri string) ([]*business.AuthorizationCodeResp, error) {
+	v, err := m.called("GetAuthorisationCode", clientId, redirectUri)
+	if v == nil {
+		return nil, err
+	}
+	return v.([]*business.AuthorizationCodeResp), err
+}
+
+// MockAccountService is a business.AccountAPI returning scripted responses.
+type MockAccountService struct {
+	Mock
+}
+
+func (m *MockAccountService) List() ([]*business.AccountResp, error) {
+	v, err := m.called("List")
+	if v == nil {
+		return nil, err
+	}
+	return v.([]*business.AccountResp), err
+}
+
+func (m *MockAccountService) WithId(id string) (*business.AccountResp, error) {
+	v, err := m.called("WithId", id)
+	if v == nil {
+		return nil, err
+	}
+	return v.(*business.AccountResp), err
+}
+
+func (m *MockAccountService) DetailWithId(id string) ([]*business.AccountDetailResp, error) {
+	v, err := m.called("DetailWithId", id)
+	if v == nil {
+		return nil, err
+	}
+	return v.([]*business.AccountDetailResp), err
+}
+
+// MockCounterpartyService is a business.CounterpartyAPI returning scripted responses.
+type MockCounterpartyService struct {
+	Mock
+}
+
+func (m *MockCounterpartyService) AddRevolut(revolutCounterparty *business.RevolutCounterpartyReq) (*business.CounterpartyResp, error) {
+	v, err := m.called("AddRevolut", revolutCounterparty)
+	return counterpartyResp(v), err
+}
+
+func (m *MockCounterpartyService) AddNonRevolut(nonRevolutCounterparty *business.NonRevolutCounterpartyReq) (*business.CounterpartyResp, error) {
+	v, err := m.called("AddNonRevolut", nonRevolutCounterparty)
+	return counterpartyResp(v), err
+}
+
+func (m *MockCounterpartyService) Delete(id string) error {
+	_, err := m.called("Delete", id)
+	return err
+}
+
+func (m *MockCounterpartyService) WithId(id string) (*business.CounterpartyResp, error) {
+	v, err := m.called("WithId", id)
+	return counterpartyResp(v), err
+}
+
+func (m *MockCounterpartyService) List() ([]*business.CounterpartyResp, error) {
+	v, err := m.called("List")
+	if v == nil {
+		return nil, err
+	}
+	return v.([]*business.CounterpartyResp), err
+}
+
+// MockTransferService is a business.TransferAPI returning scripted responses.
+type MockTransferService struct {
+	Mock
+}
+
+func (m *MockTransferService) Create(transferReq *business.TransferReq) (*business.TransferResp, error) {
+	v, err := m.called("Create", transferReq)
+	if v == nil {
+		return nil, err
+	}
+	return v.(*business.TransferResp), err
+}
+
+// MockPaymentService is a business.PaymentAPI returning scripted responses.
+type MockPaymentService struct {
+	Mock
+}
+
+func (m *MockPaymentService) Create(paymentReq *business.PaymentReq) (*business.TransactionResp, error) {
+	v, err := m.called("Create", paymentReq)
+	return transactionResp(v), err
+}
+
+func (m *MockPaymentService) WithId(id string) (*business.TransactionResp, error) {
+	v, err := m.called("WithId", id)
+	return transactionResp(v), err
+}
+
+func (m *MockPaymentService) WithRequestId(requestId string) (*business.TransactionResp, error) {
+	v, err := m.called("WithRequestId", requestId)
+	return transactionResp(v), err
+}
+
+func (m *MockPaymentService) Cancel(id string) error {
+	_, err := m.called("Cancel", id)
+	return err
+}
+
+func (m *MockPaymentService) CancelTransaction(transaction *business.TransactionResp) error {
+	_, err := m.called("CancelTransaction", transaction)
+	return err
+}
+
+func (m *MockPaymentService) List(transactionReq *business.TransactionReq) ([]*business.TransactionResp, error) {
+	v, err := m.called("List", transactionReq)
+	if v == nil {
+		return nil, err
+	}
+	return v.([]*business.TransactionResp), err
+}
+
+// MockPaymentDraftService is a business.PaymentDraftAPI returning scripted responses.
+type MockPaymentDraftService struct {
+	Mock
+}
+
+func (m *MockPaymentDraftService) Create(paymentDraftReq *business.PaymentDraftReq) (*business.PaymentDraftResp, error) {
+	v, err := m.called("Create", paymentDraftReq)
+	if v == nil {
+		return nil, err
+	}
+	return v.(*business.PaymentDraftResp), err
+}
+
+func (m *MockPaymentDraftService) List() (*business.PaymentDrafts, error) {
+	v, err := m.called("List")
+	if v == nil {
+		return nil, err
+	}
+	return v.(*business.PaymentDrafts), err
+}
+
+func (m *MockPaymentDraftService) WithId(id string) (*business.PaymentDraftDetailPayment, error) {
+	v, err := m.called("WithId", id)
+	if v == nil {
+		return nil, err
+	}
+	return v.(*business.PaymentDraftDetailPayment), err
+}
+
+func (m *MockPaymentDraftService) Delete(id string) error {
+	_, err := m.called("Delete", id)
+	return err
+}
+
+// MockExchangeService is a business.ExchangeAPI returning scripted responses.
+type MockExchangeService struct {
+	Mock
+}
+
+func (m *MockExchangeService) Rate(exchangeRateReq *business.ExchangeRateReq) (*business.ExchangeRateResp, error) {
+	v, err := m.called("Rate", exchangeRateReq)
+	if v == nil {
+		return nil, err
+	}
+	return v.(*business.ExchangeRateResp), err
+}
+
+func (m *MockExchangeService) Exchange(exchangeReq *business.ExchangeReq) (*business.ExchangeResp, error) {
+	v, err := m.called("Exchange", exchangeReq)
+	if v == nil {
+		return nil, err
+	}
+	return v.(*business.ExchangeResp), err
+}
+
+// MockWebhookService is a business.WebhookAPI returning scripted responses.
+type MockWebhookService struct {
+	Mock
+}
+
+func (m *MockWebhookService) Set(url string) error {
+	_, err := m.called("Set", url)
+	return err
+}
+
+func (m *MockWebhookService) Delete() error {
+	_, err := m.called("Delete")
+	return err
+}
+
+// MockWebhookV2Service is a business.WebhookV2API returning scripted responses.
+type MockWebhookV2Service struct {
+	Mock
+}
+
+func (m *MockWebhookV2Service) Create(webhookReq *business.WebhookV2Req) (*business.WebhookV2Resp, error) {
+	v, err := m.called("Create", webhookReq)
+	return webhookV2Resp(v), err
+}
+
+func (m *MockWebhookV2Service) List() ([]*business.WebhookV2Resp, error) {
+	v, err := m.called("List")
+	if v == nil {
+		return nil, err
+	}
+	return v.([]*business.WebhookV2Resp), err
+}
+
+func (m *MockWebhookV2Service) WithId(id string) (*business.WebhookV2Resp, error) {
+	v, err := m.called("WithId", id)
+	return webhookV2Resp(v), err
+}
+
+func (m *MockWebhookV2Service) Update(id string, webhookReq *business.WebhookV2Req) (*business.WebhookV2Resp, error) {
+	v, err := m.called("Update", id, webhookReq)
+	return webhookV2Resp(v), err
+}
+
+func (m *MockWebhookV2Service) Delete(id string) error {
+	_, err := m.called("Delete", id)
+	return err
+}
+
+func (m *MockWebhookV2Service) RotateSigningSecret(id string, expirationPeriod time.Duration) (*business.WebhookV2Resp, error) {
+	v, err := m.called("RotateSigningSecret", id, expirationPeriod)
+	return webhookV2Resp(v), err
+}
+
+func (m *MockWebhookV2Service) FailedEvents(id string, failedEventReq *business.FailedWebhookEventReq) ([]*business.FailedWebhookEvent, error) {
+	v, err := m.called("FailedEvents", id, failedEventReq)
+	if v == nil {
+		return nil, err
+	}
+	return v.([]*business.FailedWebhookEvent), err
+}
+
+// the conversions of the scripted values panic on a value of a wrong type
+
+func counterpartyResp(v interface{}) *business.CounterpartyResp {
+	if v == nil {
+		return nil
+	}
+	return v.(*business.CounterpartyResp)
+}
+
+func transactionResp(v interface{}) *business.TransactionResp {
+	if v == nil {
+		return nil
+	}
+	return v.(*business.TransactionResp)
+}
+
+func webhookV2Resp(v interface{}) *business.WebhookV2Resp {
+	if v == nil {
+		return nil
+	}
+	return v.(*business.WebhookV2Resp)
+}
+
+var (
+	_ business.API             = (*MockClient)(nil)
+	_ business.OAuthAPI        = (*MockOAuthService)(nil)
+	_ business.AccountAPI      = (*MockAccountService)(nil)
+	_ business.CounterpartyAPI = (*MockCounterpartyService)(nil)
+	_ business.TransferAPI     = (*MockTransferService)(nil)
+	_ business.PaymentAPI      = (*MockPaymentService)(nil)
+	_ business.PaymentDraftAPI = (*MockPaymentDraftService)(nil)
+	_ business.ExchangeAPI     = (*MockExchangeService)(nil)
+	_ business.WebhookAPI      = (*MockWebhookService)(nil)
+	_ business.WebhookV2API    = (*MockWebhookV2Service)(nil)
+)
@@ -0,0 +1,501 @@
+// Package businesstest provides an in-memory fake of the Revolut Business API for tests.
+package businesstest
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	business "github.com/adless-tech/go-revolut/business/1.0"
+	"github.com/adless-tech/go-revolut/webhook"
+)
+
+// Server is a stateful fake of the Business API served by an httptest.Server.
+// Payments to Revolut counterparties, transfers and exchanges complete at once,
+// payments to external counterparties stay pending until Complete or Decline is called.
+type Server struct {
+	*httptest.Server
+
+	// the refresh token accepted by auth/token
+	RefreshToken string
+	// the authorisation code accepted by auth/token
+	AuthorisationCode string
+	// the lifetime of the issued access tokens, default is 40 minutes
+	TokenTTL time.Duration
+	// the fee of the exchanges as a fraction of the amount
+	ExchangeFee float64
+
+	mu             sync.Mutex
+	tokens         map[string]time.Time
+	accounts       []*business.AccountResp
+	bankDetails    map[string][]*business.AccountDetailResp
+	counterparties []*business.CounterpartyResp
+	transactions   []*business.TransactionResp
+	rates          map[string]float64
+	drafts         []*draft
+	webhookUrl     string
+	webhooks       []*business.WebhookV2Resp
+	failures       map[string]*failure
+}
+
+type draft struct {
+	id  string
+	req *business.PaymentDraftReq
+}
+
+type failure struct {
+	status int
+	body   string
+}
+
+// NewServer starts a server without accounts, it is stopped by Close.
+func NewServer() *Server {
+	s := &Server{
+		RefreshToken:      "businesstest-refresh-token",
+		AuthorisationCode: "businesstest-code",
+		TokenTTL:          40 * time.Minute,
+		tokens:            map[string]time.Time{},
+		bankDetails:       map[string][]*business.AccountDetailResp{},
+		rates:             map[string]float64{},
+		failures:          map[string]*failure{},
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+
+	return s
+}
+
+// NewClient returns a client of the server authorised by its refresh token.
+func (s *Server) NewClient() (*business.Client, error) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return nil, err
+	}
+
+	return business.NewClientWithDomain("businesstest", s.RefreshToken, key, "example.com", s.URL)
+}
+
+// AddAccount adds an active account with the bank details of its currency.
+func (s *Server) AddAccount(name, currency string, balance float64) *business.AccountResp {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now().UTC()
+	account := &business.AccountResp{
+		Id:        newId(),
+		Name:      name,
+		Balance:   balance,
+		Currency:  currency,
+		State:     business.AccountState_ACTIVE,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	s.accounts = append(s.accounts, account)
+
+	details := &business.AccountDetailResp{
+		Beneficiary: "Businesstest Ltd",
+		BankCountry: "GB",
+		Schemes:     []business.AccountSchema{business.AccountSchema_SWIFT},
+		EstimatedTime: business.EstimatedTime{
+			Unit: business.AccountUnit_DAYS,
+			Min:  1,
+			Max:  3,
+		},
+	}
+	switch currency {
+	case "GBP":
+		details.AccountNo = fmt.Sprintf("%08d", len(s.accounts))
+		details.SortCode = "040075"
+		details.Schemes = []business.AccountSchema{business.AccountSchema_FASTER_PAYMENTS, business.AccountSchema_BACS, business.AccountSchema_CHAPS}
+		details.EstimatedTime = business.EstimatedTime{Unit: business.AccountUnit_HOURS, Max: 2}
+	case "EUR":
+		details.Iban = fmt.Sprintf("GB00REVO0099697%07d", len(s.accounts))
+		details.Bic = "REVOGB21"
+		details.Schemes = []business.AccountSchema{business.AccountSchema_SEPA, business.AccountSchema_SWIFT}
+	case "USD":
+		details.AccountNo = fmt.Sprintf("%010d", len(s.accounts))
+		details.RoutingNumber = "026073150"
+		details.Schemes = []business.AccountSchema{business.AccountSchema_ACH, business.AccountSchema_SWIFT}
+	}
+	s.bankDetails[account.Id] = []*business.AccountDetailResp{details}
+
+	c := *account
+	return &c
+}
+
+// Account returns a copy of the account or nil.
+func (s *Server) Account(id string) *business.AccountResp {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	account := s.account(id)
+	if account == nil {
+		return nil
+	}
+
+	c := *account
+	return &c
+}
+
+// SetRate sets the exchange rate of the pair, the reverse pair gets the inverse rate unless it is set.
+func (s *Server) SetRate(from, to string, rate float64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.rates[from+"/"+to] = rate
+}
+
+// Transactions returns copies of the transactions, newest first.
+func (s *Server) Transactions() []*business.TransactionResp {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	r := make([]*business.TransactionResp, 0, len(s.transactions))
+	for i := len(s.transactions) - 1; i >= 0; i-- {
+		r = append(r, copyTransaction(s.transactions[i]))
+	}
+
+	return r
+}
+
+// Complete completes a pending or created transaction.
+func (s *Server) Complete(id string) error {
+	return s.finish(id, business.PaymentState_COMPLETE, "")
+}
+
+// Decline declines a pending or created transaction with the reason code and returns the money.
+func (s *Server) Decline(id, reasonCode string) error {
+	return s.finish(id, business.PaymentState_DECLINE, reasonCode)
+}
+
+// Fail fails a pending or created transaction with the reason code and returns the money.
+func (s *Server) Fail(id, reasonCode string) error {
+	return s.finish(id, business.PaymentState_FAILED, reasonCode)
+}
+
+// FailNext makes the next request of the method to the path, e.g. /api/1.0/pay,
+// fail with the status and the body.
+func (s *Server) FailNext(method, path string, status int, body string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.failures[method+" "+path] = &failure{status: status, body: body}
+}
+
+// ExpireTokens revokes the issued access tokens, the requests made with them are answered with 401.
+func (s *Server) ExpireTokens() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for token := range s.tokens {
+		s.tokens[token] = time.Time{}
+	}
+}
+
+func (s *Server) finish(id string, state business.PaymentState, reasonCode string) error {
+	s.mu.Lock()
+
+	tx := s.transaction(id)
+	if tx == nil {
+		s.mu.Unlock()
+		return fmt.Errorf("businesstest: transaction %s not found", id)
+	}
+	if !tx.State.CanTransitionTo(state) {
+		s.mu.Unlock()
+		return fmt.Errorf("businesstest: transaction %s cannot move from %s to %s", id, tx.State, state)
+	}
+
+	old := s.setState(tx, state, reasonCode)
+	events := s.stateChangedEvents(tx, old)
+	s.mu.Unlock()
+
+	s.deliver(events)
+	return nil
+}
+
+// setState moves the transaction to the state and returns the money of failed transactions.
+func (s *Server) setState(tx *business.TransactionResp, state business.PaymentState, reasonCode string) business.PaymentState {
+	old := tx.State
+	now := time.Now().UTC()
+
+	tx.State = state
+	tx.ReasonCode = reasonCode
+	tx.UpdatedAt = now
+	if state == business.PaymentState_COMPLETE {
+		tx.CompletedAt = now
+	}
+
+	if state == business.PaymentState_DECLINE || state == business.PaymentState_FAILED || state == business.PaymentState_REVERTED {
+		for i, leg := range tx.Legs {
+			if account := s.account(leg.AccountId); account != nil {
+				account.Balance = round(account.Balance - leg.Amount)
+				account.UpdatedAt = now
+				tx.Legs[i].Balance = account.Balance
+			}
+		}
+	}
+
+	return old
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	if f, ok := s.failures[r.Method+" "+r.URL.Path]; ok {
+		delete(s.failures, r.Method+" "+r.URL.Path)
+		s.mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(f.status)
+		w.Write([]byte(f.body))
+		return
+	}
+	s.mu.Unlock()
+
+	if r.URL.Path == "/api/1.0/auth/token" && r.Method == http.MethodPost {
+		s.token(w, r)
+		return
+	}
+
+	if !s.authorised(r) {
+		writeError(w, http.StatusUnauthorized, 9002, "The request should be authorized.")
+		return
+	}
+
+	path := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if len(path) < 3 || path[0] != "api" {
+		writeError(w, http.StatusNotFound, 3000, "Not found")
+		return
+	}
+
+	var events []interface{}
+	switch path[1] + " " + r.Method + " " + path[2] {
+	case "1.0 GET accounts":
+		s.accountsEndpoint(w, r, path[3:])
+	case "1.0 POST counterparty":
+		s.addCounterparty(w, r)
+	case "1.0 GET counterparty", "1.0 DELETE counterparty":
+		s.counterparty(w, r, path[3:])
+	case "1.0 GET counterparties":
+		s.counterpartiesEndpoint(w, r)
+	case "1.0 POST pay":
+		events = s.pay(w, r)
+	case "1.0 GET transaction", "1.0 DELETE transaction":
+		events = s.transactionEndpoint(w, r, path[3:])
+	case "1.0 GET transactions":
+		s.transactionsEndpoint(w, r)
+	case "1.0 POST transfer":
+		events = s.transfer(w, r)
+	case "1.0 GET rate":
+		s.rate(w, r)
+	case "1.0 POST exchange":
+		events = s.exchange(w, r)
+	case "1.0 POST payment-drafts", "1.0 GET payment-drafts", "1.0 DELETE payment-drafts":
+		s.paymentDrafts(w, r, path[3:])
+	case "1.0 POST webhook", "1.0 DELETE webhook":
+		s.webhookV1(w, r)
+	case "2.0 POST webhooks", "2.0 GET webhooks", "2.0 PATCH webhooks", "2.0 DELETE webhooks":
+		s.webhooksV2(w, r, path[3:])
+	default:
+		writeError(w, http.StatusNotFound, 3000, "Not found")
+	}
+
+	s.deliver(events)
+}
+
+func (s *Server) authorised(r *http.Request) bool {
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	expiresAt, ok := s.tokens[token]
+	return ok && time.Now().Before(expiresAt)
+}
+
+// deliver posts the events to the web-hooks, the events of the Webhooks v2 API are signed.
+func (s *Server) deliver(events []interface{}) {
+	if len(events) == 0 {
+		return
+	}
+
+	s.mu.Lock()
+	webhookUrl := s.webhookUrl
+	hooks := make([]business.WebhookV2Resp, 0, len(s.webhooks))
+	for _, hook := range s.webhooks {
+		hooks = append(hooks, *hook)
+	}
+	s.mu.Unlock()
+
+	for _, event := range events {
+		body, err := json.Marshal(event)
+		if err != nil {
+			continue
+		}
+		var sniff struct {
+			Event business.WebhookEvent `json:"event"`
+		}
+		json.Unmarshal(body, &sniff)
+
+		if webhookUrl != "" {
+			post(webhookUrl, body, "")
+		}
+		for _, hook := range hooks {
+			if subscribed(hook.Events, sniff.Event) {
+				post(hook.Url, body, hook.SigningSecret)
+			}
+		}
+	}
+}
+
+func subscribed(events []business.WebhookEvent, event business.WebhookEvent) bool {
+	if len(events) == 0 {
+		return event == business.WebhookEvent_TRANSACTION_CREATED || event == business.WebhookEvent_TRANSACTION_STATE_CHANGED
+	}
+	for _, e := range events {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+func post(url string, body []byte, secret string) {
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if secret != "" {
+		webhook.SignRequest(req, secret, time.Now(), body)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return
+	}
+	resp.Body.Close()
+}
+
+func (s *Server) createdEvent(tx *business.TransactionResp) interface{} {
+	return &business.TransactionCreatedEvent{
+		Event:     string(business.WebhookEvent_TRANSACTION_CREATED),
+		Timestamp: time.Now().UTC(),
+		Data: business.TransactionCreatedEventData{
+			Id:           tx.Id,
+			Type:         string(tx.Type),
+			RequestId:    tx.RequestId,
+			State:        tx.State,
+			ReasonCode:   tx.ReasonCode,
+			CreatedAt:    tx.CreatedAt,
+			UpdatedAt:    tx.UpdatedAt,
+			CompletedAt:  tx.CompletedAt,
+			ScheduledFor: tx.ScheduledFor,
+			Reference:    tx.Reference,
+			Legs:         append([]business.TransactionLeg(nil), tx.Legs...),
+		},
+	}
+}
+
+func (s *Server) stateChangedEvents(tx *business.TransactionResp, old business.PaymentState) []interface{} {
+	if old == tx.State {
+		return nil
+	}
+
+	return []interface{}{&business.TransactionStateChangedEvent{
+		Event:     string(business.WebhookEvent_TRANSACTION_STATE_CHANGED),
+		Timestamp: time.Now().UTC(),
+		Data: business.TransactionStateChangedEventData{
+			ID:       tx.Id,
+			OldState: string(old),
+			NewState: string(tx.State),
+		},
+	}}
+}
+
+func (s *Server) account(id string) *business.AccountResp {
+	for _, account := range s.accounts {
+		if account.Id == id {
+			return account
+		}
+	}
+	return nil
+}
+
+func (s *Server) transaction(id string) *business.TransactionResp {
+	for _, tx := range s.transactions {
+		if tx.Id == id {
+			return tx
+		}
+	}
+	return nil
+}
+
+func (s *Server) transactionWithRequestId(requestId string) *business.TransactionResp {
+	for _, tx := range s.transactions {
+		if tx.RequestId == requestId {
+			return tx
+		}
+	}
+	return nil
+}
+
+func (s *Server) rateOf(from, to string) (float64, bool) {
+	if from == to {
+		return 1, true
+	}
+	if rate, ok := s.rates[from+"/"+to]; ok {
+		return rate, true
+	}
+	if rate, ok := s.rates[to+"/"+from]; ok && rate != 0 {
+		return 1 / rate, true
+	}
+	return 0, false
+}
+
+func copyTransaction(tx *business.TransactionResp) *business.TransactionResp {
+	c := *tx
+	c.Legs = append([]business.TransactionLeg(nil), tx.Legs...)
+	return &c
+}
+
+func sortTransactions(txs []*business.TransactionResp) {
+	sort.SliceStable(txs, func(i, j int) bool {
+		return txs[i].CreatedAt.After(txs[j].CreatedAt)
+	})
+}
+
+func writeJson(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// writeError writes an error in the format of the Business API.
+func writeError(w http.ResponseWriter, status int, code int, message string) {
+	writeJson(w, status, map[string]interface{}{
+		"code":    code,
+		"message": message,
+	})
+}
+
+func newId() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+
+	h := hex.EncodeToString(b)
+	return h[:8] + "-" + h[8:12] + "-" + h[12:16] + "-" + h[16:20] + "-" + h[20:]
+}
+
+func round(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
@@ -0,0 +1,79 @@
+package businesstest_test
+
+import (
+	"testing"
+
+	business "github.com/adless-tech/go-revolut/business/1.0"
+	"github.com/adless-tech/go-revolut/business/1.0/businesstest"
+)
+
+func TestTransferReplay(t *testing.T) {
+	srv := businesstest.NewServer()
+	defer srv.Close()
+
+	main := srv.AddAccount("Main", "GBP", 1000)
+	savings := srv.AddAccount("Savings", "GBP", 0)
+
+	bC, err := srv.NewClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req := &business.TransferReq{
+		RequestId:       "transfer-1",
+		SourceAccountId: main.Id,
+		TargetAccountId: savings.Id,
+		Amount:          100,
+		Currency:        "GBP",
+	}
+	first, err := bC.Transfer().Create(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := bC.Transfer().Create(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if first.Id != second.Id {
+		t.Errorf("replayed transfer id = %s, want %s", second.Id, first.Id)
+	}
+	if balance := srv.Account(main.Id).Balance; balance != 900 {
+		t.Errorf("main balance = %v, want 900", balance)
+	}
+	if balance := srv.Account(savings.Id).Balance; balance != 100 {
+		t.Errorf("savings balance = %v, want 100", balance)
+	}
+}
+
+func TestExpiredTokens(t *testing.T) {
+	srv := businesstest.NewServer()
+	defer srv.Close()
+
+	srv.AddAccount("Main", "GBP", 1000)
+
+	bC, err := srv.NewClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := bC.Account().List(); err != nil {
+		t.Fatal(err)
+	}
+
+	srv.ExpireTokens()
+	if _, err := bC.Account().List(); err == nil {
+		t.Error("expired token was accepted")
+	}
+
+	bC, err = srv.NewClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	accounts, err := bC.Account().List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(accounts) != 1 {
+		t.Errorf("accounts = %d, want 1", len(accounts))
+	}
+}
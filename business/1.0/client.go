@@ -8,6 +8,7 @@ import (
 type Client struct {
 	clientId     string
 	sandbox      bool
+	domain       string
 	privateKey   *rsa.PrivateKey
 	issuer       string
 	refreshToken string
@@ -18,11 +19,22 @@ type Client struct {
 }
 
 func NewClient(clientId, refreshToken string, privateKey *rsa.PrivateKey, issuer string, sandbox bool) (*Client, error) {
+	return newClient(clientId, refreshToken, privateKey, issuer, sandbox, "")
+}
+
+// NewClientWithDomain creates a client of the API served on domain instead of https://b2b.revolut.com,
+// e.g. the url of a businesstest server.
+func NewClientWithDomain(clientId, refreshToken string, privateKey *rsa.PrivateKey, issuer string, domain string) (*Client, error) {
+	return newClient(clientId, refreshToken, privateKey, issuer, false, domain)
+}
+
+func newClient(clientId, refreshToken string, privateKey *rsa.PrivateKey, issuer string, sandbox bool, domain string) (*Client, error) {
 	oa := &OAuthService{
 		clientId:   clientId,
 		privateKey: privateKey,
 		issuer:     issuer,
-		sandbox:    sandbox}
+		sandbox:    sandbox,
+		domain:     domain}
 
 	accessTokenExpiration := time.Now().Unix()
 	accessToken, err := oa.RefreshAccessToken(refreshToken)
@@ -34,6 +46,7 @@ func NewClient(clientId, refreshToken string, privateKey *rsa.PrivateKey, issuer
 	return &Client{
 		clientId:     clientId,
 		sandbox:      sandbox,
+		domain:       domain,
 		privateKey:   privateKey,
 		issuer:       issuer,
 		refreshToken: refreshToken,
@@ -44,58 +57,74 @@ func NewClient(clientId, refreshToken string, privateKey *rsa.PrivateKey, issuer
 	}, nil
 }
 
-func (b *Client) Account() *AccountService {
+func (b *Client) Account() AccountAPI {
 	return &AccountService{
 		accessToken: b.accessToken,
 		sandbox:     b.sandbox,
+		domain:      b.domain,
 		err:         b.refreshAccessToken(),
 	}
 }
 
-func (b *Client) Counterparty() *CounterpartyService {
+func (b *Client) Counterparty() CounterpartyAPI {
 	return &CounterpartyService{
 		accessToken: b.accessToken,
 		sandbox:     b.sandbox,
+		domain:      b.domain,
 		err:         b.refreshAccessToken(),
 	}
 }
 
-func (b *Client) Transfer() *TransferService {
+func (b *Client) Transfer() TransferAPI {
 	return &TransferService{
 		accessToken: b.accessToken,
 		sandbox:     b.sandbox,
+		domain:      b.domain,
 		err:         b.refreshAccessToken(),
 	}
 }
 
-func (b *Client) Payment() *PaymentService {
+func (b *Client) Payment() PaymentAPI {
 	return &PaymentService{
 		accessToken: b.accessToken,
 		sandbox:     b.sandbox,
+		domain:      b.domain,
 		err:         b.refreshAccessToken(),
 	}
 }
 
-func (b *Client) PaymentDraft() *PaymentDraftService {
+func (b *Client) PaymentDraft() PaymentDraftAPI {
 	return &PaymentDraftService{
 		accessToken: b.accessToken,
 		sandbox:     b.sandbox,
+		domain:      b.domain,
 		err:         b.refreshAccessToken(),
 	}
 }
 
-func (b *Client) Exchange() *ExchangeService {
+func (b *Client) Exchange() ExchangeAPI {
 	return &ExchangeService{
 		accessToken: b.accessToken,
 		sandbox:     b.sandbox,
+		domain:      b.domain,
 		err:         b.refreshAccessToken(),
 	}
 }
 
-func (b *Client) Webhook() *WebhookService {
+func (b *Client) Webhook() WebhookAPI {
 	return &WebhookService{
 		accessToken: b.accessToken,
 		sandbox:     b.sandbox,
+		domain:      b.domain,
+		err:         b.refreshAccessToken(),
+	}
+}
+
+func (b *Client) WebhookV2() WebhookV2API {
+	return &WebhookV2Service{
+		accessToken: b.accessToken,
+		sandbox:     b.sandbox,
+		domain:      b.domain,
 		err:         b.refreshAccessToken(),
 	}
 }
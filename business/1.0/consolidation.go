@@ -0,0 +1,158 @@
+package business
+
+import (
+	"sync"
+	"time"
+)
+
+// RateCache keeps the exchange rates for ttl to avoid asking for the same pair over and over.
+// Failed lookups are cached as well, so a missing pair is not requested on every conversion.
+type RateCache struct {
+	client API
+	ttl    time.Duration
+
+	mu    sync.Mutex
+	rates map[string]*rateCacheEntry
+}
+
+type rateCacheEntry struct {
+	rate      *ExchangeRateResp
+	err       error
+	fetchedAt time.Time
+}
+
+func NewRateCache(client API, ttl time.Duration) *RateCache {
+	return &RateCache{
+		client: client,
+		ttl:    ttl,
+		rates:  map[string]*rateCacheEntry{},
+	}
+}
+
+// Rate returns the rate of one unit of from in to.
+func (c *RateCache) Rate(from, to string) (*ExchangeRateResp, error) {
+	key := from + "/" + to
+
+	c.mu.Lock()
+	entry, ok := c.rates[key]
+	c.mu.Unlock()
+	if ok && time.Since(entry.fetchedAt) < c.ttl {
+		return entry.rate, entry.err
+	}
+
+	rate, err := c.client.Exchange().Rate(&ExchangeRateReq{From: from, To: to, Amount: 1})
+
+	c.mu.Lock()
+	c.rates[key] = &rateCacheEntry{rate: rate, err: err, fetchedAt: time.Now()}
+	c.mu.Unlock()
+
+	return rate, err
+}
+
+type ConsolidatedBalance struct {
+	// the consolidated account
+	Account *AccountResp
+	// the account balance converted to the base currency
+	Amount Amount
+	// the rate used for the conversion
+	Rate float64
+	// date of the exchange rate, the older one for a cross rate
+	RateDate time.Time
+	// the currency the cross rate was computed through, empty for a direct rate
+	Via string
+}
+
+type Consolidation struct {
+	// the base currency
+	Base string
+	// the balances of all accounts
+	Balances []*ConsolidatedBalance
+	// the sum of all balances in the base currency
+	Total Amount
+}
+
+// Consolidator sums up the balances of all accounts in a base currency.
+type Consolidator struct {
+	client API
+	rates  *RateCache
+
+	// the currencies a cross rate is computed through when a direct pair is missing,
+	// default is EUR, USD and GBP
+	Pivots []string
+}
+
+func NewConsolidator(client API, rates *RateCache) *Consolidator {
+	return &Consolidator{
+		client: client,
+		rates:  rates,
+		Pivots: []string{"EUR", "USD", "GBP"},
+	}
+}
+
+// Consolidate converts the balance of every account to base and returns them with the total.
+func (c *Consolidator) Consolidate(base string) (*Consolidation, error) {
+	accounts, err := c.client.Account().List()
+	if err != nil {
+		return nil, err
+	}
+
+	r := &Consolidation{
+		Base:  base,
+		Total: Amount{Currency: base},
+	}
+
+	now := time.Now()
+	for _, account := range accounts {
+		balance := &ConsolidatedBalance{
+			Account:  account,
+			Rate:     1,
+			RateDate: now,
+		}
+
+		if account.Currency != base {
+			rate, rateDate, via, err := c.Rate(account.Currency, base)
+			if err != nil {
+				return nil, err
+			}
+			balance.Rate, balance.RateDate, balance.Via = rate, rateDate, via
+		}
+
+		balance.Amount = Amount{Amount: roundAmount(account.Balance * balance.Rate), Currency: base}
+		r.Total.Amount += balance.Amount.Amount
+		r.Balances = append(r.Balances, balance)
+	}
+	r.Total.Amount = roundAmount(r.Total.Amount)
+
+	return r, nil
+}
+
+// Rate returns the rate between from and to, computed through one of the pivots
+// when the direct pair is missing. via is the pivot used, empty for a direct rate.
+func (c *Consolidator) Rate(from, to string) (rate float64, rateDate time.Time, via string, err error) {
+	direct, err := c.rates.Rate(from, to)
+	if err == nil {
+		return direct.Rate, direct.RateDate, "", nil
+	}
+
+	for _, pivot := range c.Pivots {
+		if pivot == from || pivot == to {
+			continue
+		}
+		first, ferr := c.rates.Rate(from, pivot)
+		if ferr != nil {
+			continue
+		}
+		second, serr := c.rates.Rate(pivot, to)
+		if serr != nil {
+			continue
+		}
+
+		rateDate = first.RateDate
+		if second.RateDate.Before(rateDate) {
+			rateDate = second.RateDate
+		}
+		return first.Rate * second.Rate, rateDate, pivot, nil
+	}
+
+	return 0, time.Time{}, "", err
+}
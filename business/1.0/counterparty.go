@@ -13,6 +13,7 @@ import (
 type CounterpartyService struct {
 	accessToken string
 	sandbox     bool
+	domain      string
 
 	err error
 }
@@ -157,6 +158,7 @@ func (c *CounterpartyService) AddRevolut(revolutCounterparty *RevolutCounterpart
 		Url:         "https://b2b.revolut.com/api/1.0/counterparty",
 		AccessToken: c.accessToken,
 		Sandbox:     c.sandbox,
+		Domain:      c.domain,
 		Body:        revolutCounterparty,
 		ContentType: request.ContentType_APPLICATION_JSON,
 	})
@@ -187,6 +189,7 @@ func (c *CounterpartyService) AddNonRevolut(nonRevolutCounterparty *NonRevolutCo
 		Url:         "https://b2b.revolut.com/api/1.0/counterparty",
 		AccessToken: c.accessToken,
 		Sandbox:     c.sandbox,
+		Domain:      c.domain,
 		ContentType: request.ContentType_APPLICATION_JSON,
 		Body:        nonRevolutCounterparty,
 	})
@@ -219,6 +222,7 @@ func (c *CounterpartyService) Delete(id string) error {
 		Url:         fmt.Sprintf("https://b2b.revolut.com/api/1.0/counterparty/%s", id),
 		AccessToken: c.accessToken,
 		Sandbox:     c.sandbox,
+		Domain:      c.domain,
 		Body:        nil,
 	})
 
@@ -245,6 +249,7 @@ func (c *CounterpartyService) WithId(id string) (*CounterpartyResp, error) {
 		Url:         fmt.Sprintf("https://b2b.revolut.com/api/1.0/counterparty/%s", id),
 		AccessToken: c.accessToken,
 		Sandbox:     c.sandbox,
+		Domain:      c.domain,
 		Body:        nil,
 	})
 	if err != nil {
@@ -275,6 +280,7 @@ func (c *CounterpartyService) List() ([]*CounterpartyResp, error) {
 		Url:         "https://b2b.revolut.com/api/1.0/counterparties",
 		AccessToken: c.accessToken,
 		Sandbox:     c.sandbox,
+		Domain:      c.domain,
 		Body:        nil,
 	})
 	if err != nil {
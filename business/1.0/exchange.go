@@ -14,6 +14,7 @@ import (
 type ExchangeService struct {
 	accessToken string
 	sandbox     bool
+	domain      string
 
 	err error
 }
@@ -92,6 +93,7 @@ func (e *ExchangeService) Rate(exchangeRateReq *ExchangeRateReq) (*ExchangeRateR
 		Url:         fmt.Sprintf("https://b2b.revolut.com/api/1.0/rate?%s", params.Encode()),
 		AccessToken: e.accessToken,
 		Sandbox:     e.sandbox,
+		Domain:      e.domain,
 	})
 	if err != nil {
 		return nil, err
@@ -120,6 +122,7 @@ func (e *ExchangeService) Exchange(exchangeReq *ExchangeReq) (*ExchangeResp, err
 		Url:         "https://b2b.revolut.com/api/1.0/exchange",
 		AccessToken: e.accessToken,
 		Sandbox:     e.sandbox,
+		Domain:      e.domain,
 		Body:        exchangeReq,
 		ContentType: request.ContentType_APPLICATION_JSON,
 	})
@@ -17,6 +17,7 @@ type OAuthService struct {
 	privateKey *rsa.PrivateKey
 	issuer     string
 	sandbox    bool
+	domain     string
 }
 
 func NewOAuth(clientId string, privateKey *rsa.PrivateKey, issuer string, sandbox bool) *OAuthService {
@@ -28,6 +29,16 @@ func NewOAuth(clientId string, privateKey *rsa.PrivateKey, issuer string, sandbo
 	}
 }
 
+// NewOAuthWithDomain creates the service for the API served on domain, e.g. a businesstest server.
+func NewOAuthWithDomain(clientId string, privateKey *rsa.PrivateKey, issuer string, domain string) *OAuthService {
+	return &OAuthService{
+		clientId:   clientId,
+		privateKey: privateKey,
+		issuer:     issuer,
+		domain:     domain,
+	}
+}
+
 const (
 	clientAssertionType = "urn:ietf:params:oauth:client-assertion-type:jwt-bearer"
 	aud                 = "https://revolut.com"
@@ -66,6 +77,7 @@ func (oa *OAuthService) ExchangeAuthorisationCode(code string) (*OAuthResp, erro
 		Method:  http.MethodPost,
 		Url:     "https://b2b.revolut.com/api/1.0/auth/token",
 		Sandbox: oa.sandbox,
+		Domain:  oa.domain,
 		Body: url.Values{
 			// "authorization_code"
 			"grant_type": []string{grant_type_authorization_code},
@@ -108,6 +120,7 @@ func (oa *OAuthService) RefreshAccessToken(refreshToken string) (*OAuthResp, err
 		Method:  http.MethodPost,
 		Url:     "https://b2b.revolut.com/api/1.0/auth/token",
 		Sandbox: oa.sandbox,
+		Domain:  oa.domain,
 		Body: url.Values{
 			"grant_type":            []string{grant_type_refresh_token},
 			"refresh_token":         []string{refreshToken},
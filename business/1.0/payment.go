@@ -14,6 +14,7 @@ import (
 type PaymentService struct {
 	accessToken string
 	sandbox     bool
+	domain      string
 
 	err error
 }
@@ -46,10 +47,12 @@ type PaymentReceiver struct {
 type PaymentState string
 
 const (
+	PaymentState_CREATED  PaymentState = "created"
 	PaymentState_PENDING  PaymentState = "pending"
 	PaymentState_COMPLETE PaymentState = "completed"
 	PaymentState_DECLINE  PaymentState = "declined"
 	PaymentState_FAILED   PaymentState = "failed"
+	PaymentState_REVERTED PaymentState = "reverted"
 )
 
 type PaymentType string
@@ -79,7 +82,7 @@ type TransactionResp struct {
 	Type PaymentType `json:"type,omitempty"`
 	// the client provided request ID
 	RequestId string `json:"request_id,omitempty,omitempty"`
-	// the transction state: pending, completed, declined or failed
+	// the transction state: created, pending, completed, declined, failed or reverted
 	State PaymentState `json:"state,omitempty"`
 	// the instant when the transaction was created
 	CreatedAt time.Time `json:"created_at,omitempty"`
@@ -181,6 +184,7 @@ func (p *PaymentService) Create(paymentReq *PaymentReq) (*TransactionResp, error
 		Url:         "https://b2b.revolut.com/api/1.0/pay",
 		AccessToken: p.accessToken,
 		Sandbox:     p.sandbox,
+		Domain:      p.domain,
 		Body:        paymentReq,
 		ContentType: request.ContentType_APPLICATION_JSON,
 	})
@@ -211,6 +215,7 @@ func (p *PaymentService) WithId(id string) (*TransactionResp, error) {
 		Url:         fmt.Sprintf("https://b2b.revolut.com/api/1.0/transaction/%s", id),
 		AccessToken: p.accessToken,
 		Sandbox:     p.sandbox,
+		Domain:      p.domain,
 	})
 	if err != nil {
 		return nil, err
@@ -239,6 +244,7 @@ func (p *PaymentService) WithRequestId(requestId string) (*TransactionResp, erro
 		Url:         fmt.Sprintf("https://b2b.revolut.com/api/1.0/transaction/%s?id_type=request_id", requestId),
 		AccessToken: p.accessToken,
 		Sandbox:     p.sandbox,
+		Domain:      p.domain,
 	})
 	if err != nil {
 		return nil, err
@@ -267,6 +273,7 @@ func (p *PaymentService) Cancel(id string) error {
 		Url:         fmt.Sprintf("https://b2b.revolut.com/api/1.0/transaction/%s", id),
 		AccessToken: p.accessToken,
 		Sandbox:     p.sandbox,
+		Domain:      p.domain,
 	})
 	if err != nil {
 		return err
@@ -307,6 +314,7 @@ func (p *PaymentService) List(transactionReq *TransactionReq) ([]*TransactionRes
 		Url:         fmt.Sprintf("https://b2b.revolut.com/api/1.0/transactions?%s", params.Encode()),
 		AccessToken: p.accessToken,
 		Sandbox:     p.sandbox,
+		Domain:      p.domain,
 	})
 	if err != nil {
 		return nil, err
@@ -12,6 +12,7 @@ import (
 type PaymentDraftService struct {
 	accessToken string
 	sandbox     bool
+	domain      string
 
 	err error
 }
@@ -117,6 +118,7 @@ func (e *PaymentDraftService) Create(paymentDraftReq *PaymentDraftReq) (*Payment
 		Url:         "https://b2b.revolut.com/api/1.0/payment-drafts",
 		AccessToken: e.accessToken,
 		Sandbox:     e.sandbox,
+		Domain:      e.domain,
 		Body:        paymentDraftReq,
 		ContentType: request.ContentType_APPLICATION_JSON,
 	})
@@ -147,6 +149,7 @@ func (e *PaymentDraftService) List() (*PaymentDrafts, error) {
 		Url:         "https://b2b.revolut.com/api/1.0/payment-drafts",
 		AccessToken: e.accessToken,
 		Sandbox:     e.sandbox,
+		Domain:      e.domain,
 	})
 	if err != nil {
 		return nil, err
@@ -175,6 +178,7 @@ func (e *PaymentDraftService) WithId(id string) (*PaymentDraftDetailPayment, err
 		Url:         fmt.Sprintf("https://b2b.revolut.com/api/1.0/payment-drafts/%s", id),
 		AccessToken: e.accessToken,
 		Sandbox:     e.sandbox,
+		Domain:      e.domain,
 	})
 	if err != nil {
 		return nil, err
@@ -203,6 +207,7 @@ func (e *PaymentDraftService) Delete(id string) error {
 		Url:         fmt.Sprintf("https://b2b.revolut.com/api/1.0/payment-drafts/%s", id),
 		AccessToken: e.accessToken,
 		Sandbox:     e.sandbox,
+		Domain:      e.domain,
 	})
 	if err != nil {
 		return err
@@ -0,0 +1,60 @@
+package business
+
+import (
+	"fmt"
+)
+
+var paymentTransitions = map[PaymentState][]PaymentState{
+	PaymentState_CREATED:  {PaymentState_PENDING, PaymentState_COMPLETE, PaymentState_DECLINE, PaymentState_FAILED, PaymentState_REVERTED},
+	PaymentState_PENDING:  {PaymentState_COMPLETE, PaymentState_DECLINE, PaymentState_FAILED, PaymentState_REVERTED},
+	PaymentState_COMPLETE: {PaymentState_REVERTED},
+}
+
+// CanTransitionTo reports whether a transaction can move from the state to the other one.
+// A completed transaction can still be reverted.
+func (s PaymentState) CanTransitionTo(to PaymentState) bool {
+	for _, state := range paymentTransitions[s] {
+		if state == to {
+			return true
+		}
+	}
+	return false
+}
+
+// IsTerminal reports whether the transaction will not change its state anymore.
+func (s PaymentState) IsTerminal() bool {
+	switch s {
+	case PaymentState_DECLINE, PaymentState_FAILED, PaymentState_REVERTED:
+		return true
+	}
+	return false
+}
+
+// CanCancel reports whether a transaction in the state can be cancelled, only scheduled ones can.
+func (s PaymentState) CanCancel() bool {
+	return s == PaymentState_CREATED || s == PaymentState_PENDING
+}
+
+// PaymentStateError is returned by the guarded operations when the transaction state forbids them.
+type PaymentStateError struct {
+	// the transaction ID
+	TransactionId string
+	// the operation, e.g. cancel
+	Op string
+	// the state of the transaction
+	State PaymentState
+}
+
+func (e *PaymentStateError) Error() string {
+	return fmt.Sprintf("cannot %s transaction %s in state %s", e.Op, e.TransactionId, e.State)
+}
+
+// CancelTransaction cancels the transaction when its last known state allows it,
+// otherwise it returns a *PaymentStateError without calling the API.
+func (p *PaymentService) CancelTransaction(transaction *TransactionResp) error {
+	if !transaction.State.CanCancel() {
+		return &PaymentStateError{TransactionId: transaction.Id, Op: "cancel", State: transaction.State}
+	}
+
+	return p.Cancel(transaction.Id)
+}
@@ -0,0 +1,167 @@
+package business
+
+import (
+	"context"
+	"time"
+)
+
+type CurrencyPair struct {
+	// the currency to exchange from
+	From string
+	// the currency to exchange to
+	To string
+	// the sampled amount, default is 1.00
+	Amount float64
+}
+
+// RateRecorder samples the exchange rates of the pairs into a RateStore.
+type RateRecorder struct {
+	client   API
+	store    RateStore
+	interval time.Duration
+	pairs    []CurrencyPair
+
+	// an optional callback for the samples which failed while running
+	OnError func(pair CurrencyPair, err error)
+}
+
+// NewRateRecorder creates a recorder sampling every interval, a non-positive interval defaults to one hour.
+func NewRateRecorder(client API, store RateStore, interval time.Duration, pairs ...CurrencyPair) *RateRecorder {
+	if interval <= 0 {
+		interval = time.Hour
+	}
+
+	return &RateRecorder{
+		client:   client,
+		store:    store,
+		interval: interval,
+		pairs:    pairs,
+	}
+}
+
+// Sample records the current rate of every pair and returns the first error.
+func (r *RateRecorder) Sample() error {
+	var firstErr error
+
+	for _, pair := range r.pairs {
+		if err := r.sample(pair); err != nil {
+			if r.OnError != nil {
+				r.OnError(pair, err)
+			}
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+
+	return firstErr
+}
+
+// Run samples the pairs right away and then every interval until ctx is done.
+// The failed samples are not returned, OnError is the only way to learn about them.
+func (r *RateRecorder) Run(ctx context.Context) error {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		r.Sample()
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+func (r *RateRecorder) sample(pair CurrencyPair) error {
+	amount := pair.Amount
+	if amount == 0 {
+		amount = 1
+	}
+
+	rate, err := r.client.Exchange().Rate(&ExchangeRateReq{
+		From:   pair.From,
+		To:     pair.To,
+		Amount: amount,
+	})
+	if err != nil {
+		return err
+	}
+
+	return r.store.Add(&RateRecord{
+		RecordedAt:       time.Now(),
+		ExchangeRateResp: *rate,
+	})
+}
+
+type ExchangeComparison struct {
+	// the exchange transaction
+	Transaction *TransactionResp
+	// the amount taken from the source account
+	From Amount
+	// the amount received on the target account
+	To Amount
+	// the rate the exchange was executed at
+	ExecutedRate float64
+	// the recorded rate nearest to the exchange
+	RecordedRate float64
+	// date of the recorded rate
+	RateDate time.Time
+	// relative difference of the executed rate against the recorded one, negative when worse
+	Deviation float64
+}
+
+// CompareExchanges compares the executed rates of the exchange transactions
+// with the rates recorded nearest to them. Transactions which are not exchanges
+// between two currencies or have no recorded rate are left out.
+func CompareExchanges(store RateStore, transactions []*TransactionResp) ([]*ExchangeComparison, error) {
+	var r []*ExchangeComparison
+
+	for _, transaction := range transactions {
+		if transaction.Type != PaymentType_EXCHANGE || len(transaction.Legs) != 2 {
+			continue
+		}
+
+		c := &ExchangeComparison{Transaction: transaction}
+		for _, leg := range transaction.Legs {
+			if leg.Amount < 0 {
+				c.From = Amount{Amount: -leg.Amount, Currency: leg.Currency}
+			} else {
+				c.To = Amount{Amount: leg.Amount, Currency: leg.Currency}
+			}
+		}
+		if c.From.Amount == 0 || c.To.Amount == 0 || c.From.Currency == c.To.Currency {
+			continue
+		}
+
+		record, err := store.Nearest(c.From.Currency, c.To.Currency, transaction.CreatedAt)
+		if err == ErrRateNotFound {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		c.ExecutedRate = c.To.Amount / c.From.Amount
+		c.RecordedRate = record.Rate
+		c.RateDate = record.Time()
+		c.Deviation = c.ExecutedRate/c.RecordedRate - 1
+		r = append(r, c)
+	}
+
+	return r, nil
+}
+
+// CompareExchanges compares the exchanges matching the query with the recorded rates.
+func (r *RateRecorder) CompareExchanges(transactionReq *TransactionReq) ([]*ExchangeComparison, error) {
+	req := *transactionReq
+	req.Type = PaymentType_EXCHANGE
+
+	transactions, err := r.client.Payment().List(&req)
+	if err != nil {
+		return nil, err
+	}
+
+	return CompareExchanges(r.store, transactions)
+}
@@ -0,0 +1,219 @@
+package business
+
+import (
+	"encoding/csv"
+	"errors"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+type RateRecord struct {
+	// the instant when the rate was sampled
+	RecordedAt time.Time
+	ExchangeRateResp
+}
+
+// Time returns the date of the rate, or the instant it was sampled when the date is missing.
+func (r *RateRecord) Time() time.Time {
+	if r.RateDate.IsZero() {
+		return r.RecordedAt
+	}
+	return r.RateDate
+}
+
+var ErrRateNotFound = errors.New("rate not found")
+
+// RateStore is a time-series store of exchange rates.
+type RateStore interface {
+	// Add stores the record
+	Add(record *RateRecord) error
+	// Nearest returns the record of the pair closest to t, or ErrRateNotFound
+	Nearest(from, to string, t time.Time) (*RateRecord, error)
+	// Range returns the records of the pair between start and end ordered by time
+	Range(from, to string, start, end time.Time) ([]*RateRecord, error)
+}
+
+// MemoryRateStore keeps the records in memory.
+type MemoryRateStore struct {
+	mu      sync.RWMutex
+	records map[string][]*RateRecord
+}
+
+func NewMemoryRateStore() *MemoryRateStore {
+	return &MemoryRateStore{records: map[string][]*RateRecord{}}
+}
+
+func (s *MemoryRateStore) Add(record *RateRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := record.From.Currency + "/" + record.To.Currency
+	records := s.records[key]
+	i := sort.Search(len(records), func(i int) bool {
+		return records[i].Time().After(record.Time())
+	})
+	records = append(records, nil)
+	copy(records[i+1:], records[i:])
+	records[i] = record
+	s.records[key] = records
+
+	return nil
+}
+
+func (s *MemoryRateStore) Nearest(from, to string, t time.Time) (*RateRecord, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	records := s.records[from+"/"+to]
+	if len(records) == 0 {
+		return nil, ErrRateNotFound
+	}
+
+	i := sort.Search(len(records), func(i int) bool {
+		return !records[i].Time().Before(t)
+	})
+	if i == len(records) {
+		return records[i-1], nil
+	}
+	if i > 0 && t.Sub(records[i-1].Time()) < records[i].Time().Sub(t) {
+		return records[i-1], nil
+	}
+
+	return records[i], nil
+}
+
+func (s *MemoryRateStore) Range(from, to string, start, end time.Time) ([]*RateRecord, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var r []*RateRecord
+	for _, record := range s.records[from+"/"+to] {
+		if !record.Time().Before(start) && !record.Time().After(end) {
+			r = append(r, record)
+		}
+	}
+
+	return r, nil
+}
+
+var rateCsvHeader = []string{"recorded_at", "rate_date", "from", "from_amount", "to", "to_amount", "rate", "fee", "fee_currency"}
+
+// CSVRateStore appends the records to a CSV file and serves the lookups from memory.
+type CSVRateStore struct {
+	*MemoryRateStore
+
+	mu   sync.Mutex
+	file *os.File
+	w    *csv.Writer
+}
+
+// OpenCSVRateStore opens the file, loads the records already in it
+// and creates the file when it does not exist.
+func OpenCSVRateStore(filename string) (*CSVRateStore, error) {
+	file, err := os.OpenFile(filename, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &CSVRateStore{
+		MemoryRateStore: NewMemoryRateStore(),
+		file:            file,
+		w:               csv.NewWriter(file),
+	}
+
+	lines, err := csv.NewReader(file).ReadAll()
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	if len(lines) == 0 {
+		if err := s.write(rateCsvHeader); err != nil {
+			file.Close()
+			return nil, err
+		}
+	}
+	for i, line := range lines {
+		if i == 0 {
+			continue
+		}
+		record, err := parseRateCsv(line)
+		if err != nil {
+			file.Close()
+			return nil, err
+		}
+		s.MemoryRateStore.Add(record)
+	}
+
+	return s, nil
+}
+
+func (s *CSVRateStore) Add(record *RateRecord) error {
+	err := s.write([]string{
+		record.RecordedAt.Format(time.RFC3339Nano),
+		record.RateDate.Format(time.RFC3339Nano),
+		record.From.Currency,
+		strconv.FormatFloat(record.From.Amount, 'f', -1, 64),
+		record.To.Currency,
+		strconv.FormatFloat(record.To.Amount, 'f', -1, 64),
+		strconv.FormatFloat(record.Rate, 'f', -1, 64),
+		strconv.FormatFloat(record.Fee.Amount, 'f', -1, 64),
+		record.Fee.Currency,
+	})
+	if err != nil {
+		return err
+	}
+
+	return s.MemoryRateStore.Add(record)
+}
+
+func (s *CSVRateStore) Close() error {
+	return s.file.Close()
+}
+
+func (s *CSVRateStore) write(line []string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.w.Write(line); err != nil {
+		return err
+	}
+	s.w.Flush()
+
+	return s.w.Error()
+}
+
+func parseRateCsv(line []string) (*RateRecord, error) {
+	if len(line) != len(rateCsvHeader) {
+		return nil, io.ErrUnexpectedEOF
+	}
+
+	r := &RateRecord{}
+	var err error
+	if r.RecordedAt, err = time.Parse(time.RFC3339Nano, line[0]); err != nil {
+		return nil, err
+	}
+	if r.RateDate, err = time.Parse(time.RFC3339Nano, line[1]); err != nil {
+		return nil, err
+	}
+	r.From.Currency = line[2]
+	if r.From.Amount, err = strconv.ParseFloat(line[3], 64); err != nil {
+		return nil, err
+	}
+	r.To.Currency = line[4]
+	if r.To.Amount, err = strconv.ParseFloat(line[5], 64); err != nil {
+		return nil, err
+	}
+	if r.Rate, err = strconv.ParseFloat(line[6], 64); err != nil {
+		return nil, err
+	}
+	if r.Fee.Amount, err = strconv.ParseFloat(line[7], 64); err != nil {
+		return nil, err
+	}
+	r.Fee.Currency = line[8]
+
+	return r, nil
+}
@@ -0,0 +1,460 @@
+package business
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+// RebalanceBand describes the range of balances an account should be kept in.
+// A band with AccountId applies only to that account, a band without it applies
+// to every active account in Currency which has no band of its own.
+type RebalanceBand struct {
+	// an optional ID of the account
+	AccountId string
+	// the account currency
+	Currency string
+	// the lowest balance still inside the band
+	Min float64
+	// the balance an account outside of the band is brought back to
+	Target float64
+	// the highest balance still inside the band
+	Max float64
+}
+
+// RebalanceTarget returns a band which keeps the accounts in currency at exactly target.
+func RebalanceTarget(currency string, target float64) RebalanceBand {
+	return RebalanceBand{Currency: currency, Min: target, Target: target, Max: target}
+}
+
+type RebalanceConfig struct {
+	// the bands the accounts should be kept in, accounts without a band are never touched
+	Bands []RebalanceBand
+	// an optional maximum of the total fee of a plan
+	FeeBudget *Amount
+	// the smallest amount worth moving, default is 0.01
+	MinAmount float64
+	// a key of the run used to derive the request IDs, default is the current UTC date
+	RunKey string
+	// an optional textual reference shown on the transactions
+	Reference string
+}
+
+type RebalanceOpType string
+
+const (
+	RebalanceOpType_TRANSFER RebalanceOpType = "transfer"
+	RebalanceOpType_EXCHANGE RebalanceOpType = "exchange"
+)
+
+type RebalanceOp struct {
+	// transfer for accounts in the same currency, exchange otherwise
+	Type RebalanceOpType
+	// the request ID derived from the run key and the operation
+	RequestId string
+	// the source account and the amount taken from it
+	From ExchangeAmount
+	// the target account and the estimated amount it receives
+	To ExchangeAmount
+	// the exchange rate used for the estimate, 1 for transfers
+	Rate float64
+	// the estimated fee of the operation
+	Fee Amount
+}
+
+type RebalancePlan struct {
+	// the operations in the order they should be executed
+	Ops []*RebalanceOp
+	// the estimated fees of the plan per currency
+	Fees []Amount
+	// the total fee in the currency of the fee budget, set only when a budget is configured
+	FeeTotal Amount
+	// the IDs of the accounts which stay outside of their band after the plan is executed
+	Unbalanced []string
+}
+
+type RebalanceResult struct {
+	Op *RebalanceOp
+	// the ID of the created transaction
+	Id string
+	// the state of the transaction
+	State string
+	// true when the transaction with the request ID already existed
+	Skipped bool
+}
+
+var ErrFeeBudgetExceeded = errors.New("rebalance: fee budget exceeded")
+
+// Rebalancer moves money between the accounts of the business so that every
+// account ends up inside of its band, using transfers within a currency and
+// exchanges across currencies.
+type Rebalancer struct {
+	client API
+	conf   RebalanceConfig
+}
+
+func NewRebalancer(client API, conf RebalanceConfig) *Rebalancer {
+	if conf.MinAmount <= 0 {
+		conf.MinAmount = 0.01
+	}
+	if conf.RunKey == "" {
+		conf.RunKey = time.Now().UTC().Format("2006-01-02")
+	}
+
+	return &Rebalancer{
+		client: client,
+		conf:   conf,
+	}
+}
+
+type rebalancePosition struct {
+	account *AccountResp
+	band    RebalanceBand
+	balance float64
+}
+
+// deficit is the amount needed to bring an account below its band back to target
+func (p *rebalancePosition) deficit() float64 {
+	if p.balance < p.band.Min {
+		return p.band.Target - p.balance
+	}
+	return 0
+}
+
+// excess is the amount to be taken from an account above its band to bring it back to target
+func (p *rebalancePosition) excess() float64 {
+	if p.balance > p.band.Max {
+		return p.balance - p.band.Target
+	}
+	return 0
+}
+
+// spare is the amount which can be taken from an account without leaving its band
+func (p *rebalancePosition) spare() float64 {
+	return math.Max(p.balance-p.band.Min, 0)
+}
+
+// room is the amount which can be sent to an account without leaving its band
+func (p *rebalancePosition) room() float64 {
+	return math.Max(p.band.Max-p.balance, 0)
+}
+
+// Plan computes the operations needed to bring every account inside of its band.
+// Shortfalls are covered from accounts above their band first and from the spare
+// balance of the other accounts afterwards, preferring accounts in the same currency.
+// The plan is returned together with ErrFeeBudgetExceeded when it is over budget.
+func (r *Rebalancer) Plan() (*RebalancePlan, error) {
+	positions, err := r.positions()
+	if err != nil {
+		return nil, err
+	}
+
+	rates := map[string]float64{}
+	rate := func(from, to string) (float64, error) {
+		if from == to {
+			return 1, nil
+		}
+		if rate, ok := rates[from+to]; ok {
+			return rate, nil
+		}
+		resp, err := r.client.Exchange().Rate(&ExchangeRateReq{From: from, To: to, Amount: 1})
+		if err != nil {
+			return 0, err
+		}
+		rates[from+to] = resp.Rate
+		return resp.Rate, nil
+	}
+
+	plan := &RebalancePlan{}
+
+	move := func(src, dst *rebalancePosition, amount float64) error {
+		rt, err := rate(src.band.Currency, dst.band.Currency)
+		if err != nil {
+			return err
+		}
+		amount = roundAmount(amount)
+		op := &RebalanceOp{
+			Type: RebalanceOpType_TRANSFER,
+			From: ExchangeAmount{AccountId: src.account.Id, Amount: amount, Currency: src.band.Currency},
+			To:   ExchangeAmount{AccountId: dst.account.Id, Amount: roundAmount(amount * rt), Currency: dst.band.Currency},
+			Rate: rt,
+			Fee:  Amount{Currency: src.band.Currency},
+		}
+		if src.band.Currency != dst.band.Currency {
+			op.Type = RebalanceOpType_EXCHANGE
+		}
+		src.balance -= op.From.Amount
+		dst.balance += op.To.Amount
+		plan.Ops = append(plan.Ops, op)
+		return nil
+	}
+
+	// cover the shortfalls
+	for _, dst := range positions {
+		tiers := []func(p *rebalancePosition) float64{
+			(*rebalancePosition).excess,
+			(*rebalancePosition).spare,
+		}
+		for _, available := range tiers {
+			for _, src := range rebalanceCandidates(positions, dst, available) {
+				need := dst.deficit()
+				if need < r.conf.MinAmount {
+					break
+				}
+				rt, err := rate(src.band.Currency, dst.band.Currency)
+				if err != nil {
+					return nil, err
+				}
+				amount := math.Min(need/rt, available(src))
+				if amount < r.conf.MinAmount {
+					continue
+				}
+				if err := move(src, dst, amount); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+
+	// place the excesses
+	for _, src := range positions {
+		for _, dst := range rebalanceCandidates(positions, src, (*rebalancePosition).room) {
+			excess := src.excess()
+			if excess < r.conf.MinAmount {
+				break
+			}
+			rt, err := rate(src.band.Currency, dst.band.Currency)
+			if err != nil {
+				return nil, err
+			}
+			amount := math.Min(excess, dst.room()/rt)
+			if amount < r.conf.MinAmount {
+				continue
+			}
+			if err := move(src, dst, amount); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	for _, p := range positions {
+		if p.deficit() >= r.conf.MinAmount || p.excess() >= r.conf.MinAmount {
+			plan.Unbalanced = append(plan.Unbalanced, p.account.Id)
+		}
+	}
+
+	fees := map[string]float64{}
+	for i, op := range plan.Ops {
+		op.RequestId = DeriveRequestId(r.conf.RunKey, fmt.Sprint(i), string(op.Type),
+			op.From.AccountId, op.To.AccountId, fmt.Sprintf("%0.2f", op.From.Amount))
+
+		if op.Type != RebalanceOpType_EXCHANGE {
+			continue
+		}
+		quote, err := r.client.Exchange().Rate(&ExchangeRateReq{
+			From:   op.From.Currency,
+			To:     op.To.Currency,
+			Amount: op.From.Amount,
+		})
+		if err != nil {
+			return nil, err
+		}
+		op.To.Amount = quote.To.Amount
+		op.Rate = quote.Rate
+		op.Fee = quote.Fee
+		fees[quote.Fee.Currency] += quote.Fee.Amount
+	}
+
+	currencies := make([]string, 0, len(fees))
+	for currency := range fees {
+		currencies = append(currencies, currency)
+	}
+	sort.Strings(currencies)
+	for _, currency := range currencies {
+		plan.Fees = append(plan.Fees, Amount{Amount: roundAmount(fees[currency]), Currency: currency})
+	}
+
+	if r.conf.FeeBudget == nil {
+		return plan, nil
+	}
+
+	plan.FeeTotal = Amount{Currency: r.conf.FeeBudget.Currency}
+	for _, fee := range plan.Fees {
+		rt, err := rate(fee.Currency, r.conf.FeeBudget.Currency)
+		if err != nil {
+			return nil, err
+		}
+		plan.FeeTotal.Amount += fee.Amount * rt
+	}
+	plan.FeeTotal.Amount = roundAmount(plan.FeeTotal.Amount)
+	if plan.FeeTotal.Amount > r.conf.FeeBudget.Amount {
+		return plan, ErrFeeBudgetExceeded
+	}
+
+	return plan, nil
+}
+
+// Execute runs the operations of the plan in order and stops at the first error.
+// Operations whose request ID is already known to Revolut are skipped,
+// so an interrupted plan can be executed again safely.
+func (r *Rebalancer) Execute(plan *RebalancePlan) ([]*RebalanceResult, error) {
+	var results []*RebalanceResult
+
+	for _, op := range plan.Ops {
+		if tx, err := r.client.Payment().WithRequestId(op.RequestId); err == nil {
+			results = append(results, &RebalanceResult{Op: op, Id: tx.Id, State: string(tx.State), Skipped: true})
+			continue
+		}
+
+		result := &RebalanceResult{Op: op}
+		switch op.Type {
+		case RebalanceOpType_TRANSFER:
+			resp, err := r.client.Transfer().Create(&TransferReq{
+				RequestId:       op.RequestId,
+				SourceAccountId: op.From.AccountId,
+				TargetAccountId: op.To.AccountId,
+				Amount:          op.From.Amount,
+				Currency:        op.From.Currency,
+				Reference:       r.conf.Reference,
+			})
+			if err != nil {
+				return results, err
+			}
+			result.Id, result.State = resp.Id, resp.State
+
+		case RebalanceOpType_EXCHANGE:
+			resp, err := r.client.Exchange().Exchange(&ExchangeReq{
+				From:      ExchangeAmount{AccountId: op.From.AccountId, Amount: op.From.Amount, Currency: op.From.Currency},
+				To:        ExchangeAmount{AccountId: op.To.AccountId, Currency: op.To.Currency},
+				Reference: r.conf.Reference,
+				RequestId: op.RequestId,
+			})
+			if err != nil {
+				return results, err
+			}
+			result.Id, result.State = resp.Id, resp.State
+		}
+		results = append(results, result)
+	}
+
+	return results, nil
+}
+
+// String renders the plan for a dry run.
+func (p *RebalancePlan) String() string {
+	var b strings.Builder
+
+	if len(p.Ops) == 0 {
+		b.WriteString("nothing to rebalance\n")
+	}
+	for i, op := range p.Ops {
+		fmt.Fprintf(&b, "%d. %s %0.2f %s from %s to %s, receives %0.2f %s",
+			i+1, op.Type, op.From.Amount, op.From.Currency, op.From.AccountId,
+			op.To.AccountId, op.To.Amount, op.To.Currency)
+		if op.Type == RebalanceOpType_EXCHANGE {
+			fmt.Fprintf(&b, " (rate %g, fee %0.2f %s)", op.Rate, op.Fee.Amount, op.Fee.Currency)
+		}
+		fmt.Fprintf(&b, " [%s]\n", op.RequestId)
+	}
+	for _, fee := range p.Fees {
+		fmt.Fprintf(&b, "fee: %0.2f %s\n", fee.Amount, fee.Currency)
+	}
+	if p.FeeTotal.Currency != "" {
+		fmt.Fprintf(&b, "fee total: %0.2f %s\n", p.FeeTotal.Amount, p.FeeTotal.Currency)
+	}
+	for _, id := range p.Unbalanced {
+		fmt.Fprintf(&b, "still outside of band: %s\n", id)
+	}
+
+	return b.String()
+}
+
+func (r *Rebalancer) positions() ([]*rebalancePosition, error) {
+	byAccount := map[string]RebalanceBand{}
+	byCurrency := map[string]RebalanceBand{}
+	for _, band := range r.conf.Bands {
+		if band.Min > band.Target || band.Target > band.Max {
+			return nil, fmt.Errorf("rebalance: invalid band %+v, expected min <= target <= max", band)
+		}
+		if band.AccountId != "" {
+			byAccount[band.AccountId] = band
+		} else {
+			byCurrency[band.Currency] = band
+		}
+	}
+
+	accounts, err := r.client.Account().List()
+	if err != nil {
+		return nil, err
+	}
+
+	var positions []*rebalancePosition
+	for _, account := range accounts {
+		if account.State != AccountState_ACTIVE {
+			continue
+		}
+		band, ok := byAccount[account.Id]
+		if !ok {
+			band, ok = byCurrency[account.Currency]
+		}
+		if !ok {
+			continue
+		}
+		band.Currency = account.Currency
+		positions = append(positions, &rebalancePosition{
+			account: account,
+			band:    band,
+			balance: account.Balance,
+		})
+	}
+
+	sort.Slice(positions, func(i, j int) bool {
+		if positions[i].band.Currency != positions[j].band.Currency {
+			return positions[i].band.Currency < positions[j].band.Currency
+		}
+		return positions[i].account.Id < positions[j].account.Id
+	})
+
+	return positions, nil
+}
+
+// rebalanceCandidates returns the counterparts of p with something available,
+// the ones in the same currency first and the largest ones first within a currency
+func rebalanceCandidates(positions []*rebalancePosition, p *rebalancePosition, available func(*rebalancePosition) float64) []*rebalancePosition {
+	var candidates []*rebalancePosition
+	for _, c := range positions {
+		if c != p && available(c) > 0 {
+			candidates = append(candidates, c)
+		}
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		si := candidates[i].band.Currency == p.band.Currency
+		sj := candidates[j].band.Currency == p.band.Currency
+		if si != sj {
+			return si
+		}
+		if si {
+			return available(candidates[i]) > available(candidates[j])
+		}
+		return false
+	})
+
+	return candidates
+}
+
+// DeriveRequestId returns a deterministic request ID (40 characters) for the given parts,
+// so a retried operation is recognised as a duplicate by Revolut.
+func DeriveRequestId(parts ...string) string {
+	sum := sha1.Sum([]byte(strings.Join(parts, "|")))
+	return hex.EncodeToString(sum[:])
+}
+
+func roundAmount(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
@@ -7,6 +7,10 @@ import (
 	"io/ioutil"
 	"net/http"
 	"net/url"
+	"strings"
+	"time"
+
+	"github.com/adless-tech/go-revolut/logging"
 )
 
 type Config struct {
@@ -14,10 +18,21 @@ type Config struct {
 	Url         string
 	AccessToken string
 	Sandbox     bool
+	// an optional domain replacing https://b2b.revolut.com in Url, e.g. the url of a fake server
+	Domain      string
 	Body        interface{}
 	ContentType ContentType
 }
 
+const productionDomain = "https://b2b.revolut.com"
+
+// Transport sends the requests of all the clients, nil uses http.DefaultTransport.
+// Tests set it to a cassette.Recorder or a cassette.Player.
+var Transport http.RoundTripper
+
+// Logger is an optional logger of all the calls.
+var Logger *logging.Logger
+
 type ContentType string
 
 const (
@@ -41,7 +56,9 @@ func New(conf Config) ([]byte, int, error) {
 		}
 	}
 
-	if conf.Sandbox {
+	if conf.Domain != "" && strings.HasPrefix(conf.Url, productionDomain) {
+		conf.Url = strings.TrimSuffix(conf.Domain, "/") + conf.Url[len(productionDomain):]
+	} else if conf.Sandbox {
 		conf.Url = fmt.Sprintf("%ssandbox-%s", conf.Url[:8], conf.Url[8:])
 	}
 
@@ -52,17 +69,48 @@ func New(conf Config) ([]byte, int, error) {
 
 	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", conf.AccessToken))
 
-	c := &http.Client{}
+	c := &http.Client{Transport: Transport}
 
+	start := time.Now()
 	resp, err := c.Do(req)
 	if err != nil {
+		logCall(req, requestId(b), b, nil, 0, start, err)
 		return []byte{}, 0, err
 	}
+	defer resp.Body.Close()
 
-	b, err = ioutil.ReadAll(resp.Body)
+	respBody, err := ioutil.ReadAll(resp.Body)
+	logCall(req, requestId(b), b, respBody, resp.StatusCode, start, err)
 	if err != nil {
 		return []byte{}, 0, err
 	}
 
-	return b, resp.StatusCode, nil
+	return respBody, resp.StatusCode, nil
+}
+
+// requestId returns the request_id of a JSON body, which makes the payments and the transfers idempotent.
+func requestId(body []byte) string {
+	r := struct {
+		RequestId string `json:"request_id"`
+	}{}
+	if len(body) == 0 || body[0] != '{' || json.Unmarshal(body, &r) != nil {
+		return ""
+	}
+
+	return r.RequestId
+}
+
+func logCall(req *http.Request, requestId string, requestBody, responseBody []byte, status int, start time.Time, err error) {
+	if Logger == nil {
+		return
+	}
+
+	Logger.Log(&logging.Record{
+		Method:    req.Method,
+		Path:      req.URL.Path,
+		Status:    status,
+		Latency:   time.Since(start),
+		RequestId: requestId,
+		Err:       err,
+	}, requestBody, responseBody)
 }
@@ -0,0 +1,293 @@
+package business
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+)
+
+type SweepRuleType string
+
+const (
+	// keep the threshold in the account and move the excess to the counterpart
+	SweepRuleType_EXCESS SweepRuleType = "excess"
+	// top the account up to the threshold from the counterpart
+	SweepRuleType_TOP_UP SweepRuleType = "top_up"
+)
+
+type SweepSchedule struct {
+	// the length of the period, default is one day
+	Every time.Duration
+	// the offset from the start of the period the rule is due at, e.g. 7 * time.Hour for 07:00 daily
+	At time.Duration
+}
+
+type SweepRule struct {
+	// the unique name of the rule, it is a part of the request IDs
+	Name string
+	// excess or top_up
+	Type SweepRuleType
+	// the ID or the name of the governed account
+	Account string
+	// the ID or the name of the account the excess goes to or the top up comes from
+	Counterpart string
+	// the balance kept in the account or topped up to
+	Threshold float64
+	// the smallest amount worth transferring, default is 0.01
+	MinAmount float64
+	// when the rule runs in the scheduler
+	Schedule SweepSchedule
+	// an optional textual reference shown on the transaction
+	Reference string
+}
+
+type SweepTransfer struct {
+	// the rule the transfer was made by
+	Rule *SweepRule
+	// the start of the period the transfer belongs to
+	Period time.Time
+	// the transfer request, its request ID is derived from the rule name and the period
+	Req *TransferReq
+	// the created transfer, nil in dry run
+	Transfer *TransferResp
+	// the error of the transfer
+	Err error
+}
+
+// SweepEngine moves cash between accounts of the same currency by declarative rules.
+// Every rule makes at most one transfer per period, as the request ID
+// of the transfer is derived from the rule name and the period.
+type SweepEngine struct {
+	client API
+	rules  []*SweepRule
+
+	// mu serialises the runs and guards done
+	mu   sync.Mutex
+	done map[string]bool
+
+	// plan the transfers without making them
+	DryRun bool
+	// the location the periods are aligned in, default is UTC
+	Location *time.Location
+	// an optional callback for every transfer made by the scheduler
+	OnTransfer func(transfer *SweepTransfer)
+	// an optional callback for the ticks of the scheduler which failed, e.g. when the accounts
+	// could not be listed, it gets the first error of the tick
+	OnError func(err error)
+}
+
+func NewSweepEngine(client API, rules ...*SweepRule) (*SweepEngine, error) {
+	names := map[string]bool{}
+	for _, rule := range rules {
+		if rule.Name == "" || names[rule.Name] {
+			return nil, fmt.Errorf("sweep: rule name %q is empty or not unique", rule.Name)
+		}
+		names[rule.Name] = true
+
+		if rule.Type != SweepRuleType_EXCESS && rule.Type != SweepRuleType_TOP_UP {
+			return nil, fmt.Errorf("sweep: rule %s has unknown type %q", rule.Name, rule.Type)
+		}
+		if rule.Account == "" || rule.Counterpart == "" || rule.Account == rule.Counterpart {
+			return nil, fmt.Errorf("sweep: rule %s needs two different accounts", rule.Name)
+		}
+	}
+
+	return &SweepEngine{
+		client:   client,
+		rules:    rules,
+		done:     map[string]bool{},
+		Location: time.UTC,
+	}, nil
+}
+
+// RunOnce applies every rule for the period containing now.
+// It returns the transfers together with the first error.
+func (e *SweepEngine) RunOnce(now time.Time) ([]*SweepTransfer, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	return e.run(now, e.rules)
+}
+
+// Run applies each rule once per period after it becomes due, checking every tick until ctx is done.
+// Failed rules are retried on the next tick, their errors are reported by OnError.
+func (e *SweepEngine) Run(ctx context.Context, tick time.Duration) error {
+	if tick <= 0 {
+		return fmt.Errorf("sweep: tick %s is not positive", tick)
+	}
+
+	ticker := time.NewTicker(tick)
+	defer ticker.Stop()
+
+	for {
+		transfers, err := e.runDue(time.Now())
+		for _, transfer := range transfers {
+			if e.OnTransfer != nil {
+				e.OnTransfer(transfer)
+			}
+		}
+		if err != nil && e.OnError != nil {
+			e.OnError(err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// runDue applies the rules which are due at now and were not applied in their period yet.
+func (e *SweepEngine) runDue(now time.Time) ([]*SweepTransfer, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	var due []*SweepRule
+	for _, rule := range e.rules {
+		period := e.period(rule, now)
+		if !now.Before(period.Add(rule.Schedule.At)) && !e.done[e.key(rule, period)] {
+			due = append(due, rule)
+		}
+	}
+	if len(due) == 0 {
+		return nil, nil
+	}
+
+	return e.run(now, due)
+}
+
+func (e *SweepEngine) run(now time.Time, rules []*SweepRule) ([]*SweepTransfer, error) {
+	accounts, err := e.client.Account().List()
+	if err != nil {
+		return nil, err
+	}
+
+	var transfers []*SweepTransfer
+	var firstErr error
+	fail := func(transfer *SweepTransfer, err error) {
+		transfer.Err = err
+		if firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	for _, rule := range rules {
+		period := e.period(rule, now)
+		transfer := &SweepTransfer{Rule: rule, Period: period}
+
+		account, err := findSweepAccount(accounts, rule.Account)
+		if err != nil {
+			fail(transfer, err)
+			transfers = append(transfers, transfer)
+			continue
+		}
+		counterpart, err := findSweepAccount(accounts, rule.Counterpart)
+		if err != nil {
+			fail(transfer, err)
+			transfers = append(transfers, transfer)
+			continue
+		}
+		if account.Currency != counterpart.Currency {
+			fail(transfer, fmt.Errorf("sweep: rule %s: accounts %s and %s are in different currencies", rule.Name, account.Id, counterpart.Id))
+			transfers = append(transfers, transfer)
+			continue
+		}
+
+		source, target := account, counterpart
+		amount := account.Balance - rule.Threshold
+		if rule.Type == SweepRuleType_TOP_UP {
+			source, target = counterpart, account
+			amount = math.Min(rule.Threshold-account.Balance, counterpart.Balance)
+		}
+
+		minAmount := rule.MinAmount
+		if minAmount <= 0 {
+			minAmount = 0.01
+		}
+		amount = roundAmount(amount)
+		if amount < minAmount {
+			if !e.DryRun {
+				e.done[e.key(rule, period)] = true
+			}
+			continue
+		}
+
+		transfer.Req = &TransferReq{
+			RequestId:       DeriveRequestId("sweep", e.key(rule, period)),
+			SourceAccountId: source.Id,
+			TargetAccountId: target.Id,
+			Amount:          amount,
+			Currency:        source.Currency,
+			Reference:       rule.Reference,
+		}
+		transfers = append(transfers, transfer)
+
+		if !e.DryRun {
+			resp, err := e.client.Transfer().Create(transfer.Req)
+			if err != nil {
+				fail(transfer, err)
+				continue
+			}
+			transfer.Transfer = resp
+			e.done[e.key(rule, period)] = true
+		}
+
+		// the following rules see the balances after the transfer
+		source.Balance -= amount
+		target.Balance += amount
+	}
+
+	return transfers, firstErr
+}
+
+func (e *SweepEngine) period(rule *SweepRule, t time.Time) time.Time {
+	every := rule.Schedule.Every
+	if every == 0 {
+		every = 24 * time.Hour
+	}
+
+	location := e.Location
+	if location == nil {
+		location = time.UTC
+	}
+	t = t.In(location)
+
+	if every == 24*time.Hour {
+		y, m, d := t.Date()
+		return time.Date(y, m, d, 0, 0, 0, 0, location)
+	}
+
+	// truncate the wall clock time of the location, not the UTC one
+	_, offset := t.Zone()
+	shift := time.Duration(offset) * time.Second
+	return t.Add(shift).Truncate(every).Add(-shift)
+}
+
+func (e *SweepEngine) key(rule *SweepRule, period time.Time) string {
+	return rule.Name + "|" + period.Format(time.RFC3339)
+}
+
+func findSweepAccount(accounts []*AccountResp, idOrName string) (*AccountResp, error) {
+	var found *AccountResp
+	for _, account := range accounts {
+		if account.Id == idOrName {
+			return account, nil
+		}
+		if account.Name == idOrName {
+			if found != nil {
+				return nil, fmt.Errorf("sweep: account name %q is ambiguous", idOrName)
+			}
+			found = account
+		}
+	}
+
+	if found == nil {
+		return nil, errors.New("sweep: account " + idOrName + " not found")
+	}
+
+	return found, nil
+}
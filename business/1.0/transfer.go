@@ -12,6 +12,7 @@ import (
 type TransferService struct {
 	accessToken string
 	sandbox     bool
+	domain      string
 
 	err error
 }
@@ -64,6 +65,7 @@ func (t *TransferService) Create(transferReq *TransferReq) (*TransferResp, error
 		Url:         "https://b2b.revolut.com/api/1.0/transfer",
 		AccessToken: t.accessToken,
 		Sandbox:     t.sandbox,
+		Domain:      t.domain,
 		Body:        transferReq,
 		ContentType: request.ContentType_APPLICATION_JSON,
 	})
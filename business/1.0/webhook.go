@@ -11,6 +11,7 @@ import (
 type WebhookService struct {
 	accessToken string
 	sandbox     bool
+	domain      string
 
 	err error
 }
@@ -76,6 +77,7 @@ func (p *WebhookService) Set(url string) error {
 		Url:         "https://b2b.revolut.com/api/1.0/webhook",
 		AccessToken: p.accessToken,
 		Sandbox:     p.sandbox,
+		Domain:      p.domain,
 		Body: struct {
 			// call back endpoint of the client system, https is the supported protocol
 			Url string `json:"url,omitempty"`
@@ -104,6 +106,7 @@ func (p *WebhookService) Delete() error {
 		Url:         "https://b2b.revolut.com/api/1.0/webhook",
 		AccessToken: p.accessToken,
 		Sandbox:     p.sandbox,
+		Domain:      p.domain,
 	})
 	if err != nil {
 		return err
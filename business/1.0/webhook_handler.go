@@ -0,0 +1,133 @@
+package business
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+)
+
+type WebhookEvent string
+
+const (
+	WebhookEvent_TRANSACTION_CREATED       WebhookEvent = "TransactionCreated"
+	WebhookEvent_TRANSACTION_STATE_CHANGED WebhookEvent = "TransactionStateChanged"
+	WebhookEvent_PAYOUT_LINK_CREATED       WebhookEvent = "PayoutLinkCreated"
+	WebhookEvent_PAYOUT_LINK_STATE_CHANGED WebhookEvent = "PayoutLinkStateChanged"
+)
+
+// DefaultWebhookMaxBodySize is the largest event body accepted by WebhookHandler, 1 MB.
+const DefaultWebhookMaxBodySize = 1 << 20
+
+// WebhookHandler is an http.Handler receiving the web-hook events and
+// dispatching them to the registered callbacks. A callback error is answered
+// with 500, so Revolut delivers the event again.
+type WebhookHandler struct {
+	// the largest accepted body, default is DefaultWebhookMaxBodySize
+	MaxBodySize int64
+
+	onTransactionCreated      func(event *TransactionCreatedEvent) error
+	onTransactionStateChanged func(event *TransactionStateChangedEvent) error
+	onUnknownEvent            func(event WebhookEvent, body []byte) error
+}
+
+type webhookDecodeError struct {
+	err error
+}
+
+func (e *webhookDecodeError) Error() string {
+	return "webhook: malformed event: " + e.err.Error()
+}
+
+func NewWebhookHandler() *WebhookHandler {
+	return &WebhookHandler{MaxBodySize: DefaultWebhookMaxBodySize}
+}
+
+// OnTransactionCreated registers the callback of the TransactionCreated event.
+func (h *WebhookHandler) OnTransactionCreated(fn func(event *TransactionCreatedEvent) error) *WebhookHandler {
+	h.onTransactionCreated = fn
+	return h
+}
+
+// OnTransactionStateChanged registers the callback of the TransactionStateChanged event.
+func (h *WebhookHandler) OnTransactionStateChanged(fn func(event *TransactionStateChangedEvent) error) *WebhookHandler {
+	h.onTransactionStateChanged = fn
+	return h
+}
+
+// OnUnknownEvent registers the callback of the events without a typed callback.
+// Such events are acknowledged and dropped when it is not set.
+func (h *WebhookHandler) OnUnknownEvent(fn func(event WebhookEvent, body []byte) error) *WebhookHandler {
+	h.onUnknownEvent = fn
+	return h
+}
+
+func (h *WebhookHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	maxBodySize := h.MaxBodySize
+	if maxBodySize <= 0 {
+		maxBodySize = DefaultWebhookMaxBodySize
+	}
+
+	body, err := ioutil.ReadAll(io.LimitReader(r.Body, maxBodySize+1))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if int64(len(body)) > maxBodySize {
+		http.Error(w, http.StatusText(http.StatusRequestEntityTooLarge), http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	if err := h.Dispatch(body); err != nil {
+		var decodeErr *webhookDecodeError
+		if errors.As(err, &decodeErr) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// Dispatch decodes the event body and calls the registered callback.
+func (h *WebhookHandler) Dispatch(body []byte) error {
+	var sniff struct {
+		Event WebhookEvent `json:"event"`
+	}
+	if err := json.Unmarshal(body, &sniff); err != nil {
+		return &webhookDecodeError{err}
+	}
+	if sniff.Event == "" {
+		return &webhookDecodeError{errors.New("missing event")}
+	}
+
+	switch {
+	case sniff.Event == WebhookEvent_TRANSACTION_CREATED && h.onTransactionCreated != nil:
+		event := &TransactionCreatedEvent{}
+		if err := json.Unmarshal(body, event); err != nil {
+			return &webhookDecodeError{err}
+		}
+		return h.onTransactionCreated(event)
+
+	case sniff.Event == WebhookEvent_TRANSACTION_STATE_CHANGED && h.onTransactionStateChanged != nil:
+		event := &TransactionStateChangedEvent{}
+		if err := json.Unmarshal(body, event); err != nil {
+			return &webhookDecodeError{err}
+		}
+		return h.onTransactionStateChanged(event)
+
+	case h.onUnknownEvent != nil:
+		return h.onUnknownEvent(sniff.Event, body)
+	}
+
+	return nil
+}
@@ -0,0 +1,287 @@
+package business
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/adless-tech/go-revolut/business/1.0/request"
+)
+
+// WebhookV2Service manages the web-hooks of the Webhooks v2 API, which supports
+// multiple web-hooks with their own events and signing secrets.
+type WebhookV2Service struct {
+	accessToken string
+	sandbox     bool
+	domain      string
+
+	err error
+}
+
+type WebhookV2Req struct {
+	// call back endpoint of the client system, https is the supported protocol
+	Url string `json:"url,omitempty"`
+	// an optional list of event types to subscribe to, default is TransactionCreated and TransactionStateChanged
+	Events []WebhookEvent `json:"events,omitempty"`
+}
+
+type WebhookV2Resp struct {
+	// the ID of the web-hook
+	Id string `json:"id,omitempty"`
+	// call back endpoint of the client system
+	Url string `json:"url,omitempty"`
+	// the list of event types the web-hook is subscribed to
+	Events []WebhookEvent `json:"events,omitempty"`
+	// the signing secret of the web-hook, not returned by List
+	SigningSecret string `json:"signing_secret,omitempty"`
+}
+
+type FailedWebhookEventReq struct {
+	// an optional number of records to return (1000 max, default is 100)
+	Limit int
+	// an optional timestamp to query to, filtering on the created_at field
+	CreatedBefore time.Time
+}
+
+type FailedWebhookEvent struct {
+	// the ID of the event
+	Id string `json:"id,omitempty"`
+	// the instant when the event was created
+	CreatedAt time.Time `json:"created_at,omitempty"`
+	// the instant when the event was last updated
+	UpdatedAt time.Time `json:"updated_at,omitempty"`
+	// the ID of the web-hook
+	WebhookId string `json:"webhook_id,omitempty"`
+	// call back endpoint of the web-hook
+	WebhookUrl string `json:"webhook_url,omitempty"`
+	// the event as it was sent, it can be passed to WebhookHandler.Dispatch
+	Payload json.RawMessage `json:"payload,omitempty"`
+	// the instant of the last delivery attempt
+	LastSentDate time.Time `json:"last_sent_date,omitempty"`
+}
+
+// Create: Use this API request to create a new web-hook.
+// doc: https://developer.revolut.com/docs/business/create-webhook
+func (w *WebhookV2Service) Create(webhookReq *WebhookV2Req) (*WebhookV2Resp, error) {
+	if w.err != nil {
+		return nil, w.err
+	}
+
+	resp, statusCode, err := request.New(request.Config{
+		Method:      http.MethodPost,
+		Url:         "https://b2b.revolut.com/api/2.0/webhooks",
+		AccessToken: w.accessToken,
+		Sandbox:     w.sandbox,
+		Domain:      w.domain,
+		Body:        webhookReq,
+		ContentType: request.ContentType_APPLICATION_JSON,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if statusCode != http.StatusOK && statusCode != http.StatusCreated {
+		return nil, errors.New(string(resp))
+	}
+
+	r := &WebhookV2Resp{}
+	if err := json.Unmarshal(resp, r); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// List: This endpoint retrieves all your web-hooks.
+// doc: https://developer.revolut.com/docs/business/get-webhooks
+func (w *WebhookV2Service) List() ([]*WebhookV2Resp, error) {
+	if w.err != nil {
+		return nil, w.err
+	}
+
+	resp, statusCode, err := request.New(request.Config{
+		Method:      http.MethodGet,
+		Url:         "https://b2b.revolut.com/api/2.0/webhooks",
+		AccessToken: w.accessToken,
+		Sandbox:     w.sandbox,
+		Domain:      w.domain,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if statusCode != http.StatusOK {
+		return nil, errors.New(string(resp))
+	}
+
+	r := []*WebhookV2Resp{}
+	if err := json.Unmarshal(resp, &r); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// WithId: This endpoint retrieves a web-hook with its signing secret by ID.
+// doc: https://developer.revolut.com/docs/business/get-webhook
+func (w *WebhookV2Service) WithId(id string) (*WebhookV2Resp, error) {
+	if w.err != nil {
+		return nil, w.err
+	}
+
+	resp, statusCode, err := request.New(request.Config{
+		Method:      http.MethodGet,
+		Url:         fmt.Sprintf("https://b2b.revolut.com/api/2.0/webhooks/%s", id),
+		AccessToken: w.accessToken,
+		Sandbox:     w.sandbox,
+		Domain:      w.domain,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if statusCode != http.StatusOK {
+		return nil, errors.New(string(resp))
+	}
+
+	r := &WebhookV2Resp{}
+	if err := json.Unmarshal(resp, r); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// Update: This endpoint updates the url or the events of a web-hook.
+// doc: https://developer.revolut.com/docs/business/update-webhook
+func (w *WebhookV2Service) Update(id string, webhookReq *WebhookV2Req) (*WebhookV2Resp, error) {
+	if w.err != nil {
+		return nil, w.err
+	}
+
+	resp, statusCode, err := request.New(request.Config{
+		Method:      http.MethodPatch,
+		Url:         fmt.Sprintf("https://b2b.revolut.com/api/2.0/webhooks/%s", id),
+		AccessToken: w.accessToken,
+		Sandbox:     w.sandbox,
+		Domain:      w.domain,
+		Body:        webhookReq,
+		ContentType: request.ContentType_APPLICATION_JSON,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if statusCode != http.StatusOK {
+		return nil, errors.New(string(resp))
+	}
+
+	r := &WebhookV2Resp{}
+	if err := json.Unmarshal(resp, r); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// Delete: This endpoint deletes a web-hook with the given ID.
+// doc: https://developer.revolut.com/docs/business/delete-webhook
+func (w *WebhookV2Service) Delete(id string) error {
+	if w.err != nil {
+		return w.err
+	}
+
+	resp, statusCode, err := request.New(request.Config{
+		Method:      http.MethodDelete,
+		Url:         fmt.Sprintf("https://b2b.revolut.com/api/2.0/webhooks/%s", id),
+		AccessToken: w.accessToken,
+		Sandbox:     w.sandbox,
+		Domain:      w.domain,
+	})
+	if err != nil {
+		return err
+	}
+	if statusCode != http.StatusNoContent {
+		return errors.New(string(resp))
+	}
+
+	return nil
+}
+
+// RotateSigningSecret: This endpoint issues a new signing secret for a web-hook.
+// The old secret stays valid for expirationPeriod (7 days max), zero revokes it immediately.
+// doc: https://developer.revolut.com/docs/business/rotate-webhook-signing-secret
+func (w *WebhookV2Service) RotateSigningSecret(id string, expirationPeriod time.Duration) (*WebhookV2Resp, error) {
+	if w.err != nil {
+		return nil, w.err
+	}
+
+	body := struct {
+		// the period the old secret stays valid for, ISO 8601 duration
+		ExpirationPeriod string `json:"expiration_period,omitempty"`
+	}{}
+	if expirationPeriod > 0 {
+		body.ExpirationPeriod = fmt.Sprintf("PT%dS", int64(expirationPeriod/time.Second))
+	}
+
+	resp, statusCode, err := request.New(request.Config{
+		Method:      http.MethodPost,
+		Url:         fmt.Sprintf("https://b2b.revolut.com/api/2.0/webhooks/%s/rotate-signing-secret", id),
+		AccessToken: w.accessToken,
+		Sandbox:     w.sandbox,
+		Domain:      w.domain,
+		Body:        body,
+		ContentType: request.ContentType_APPLICATION_JSON,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if statusCode != http.StatusOK {
+		return nil, errors.New(string(resp))
+	}
+
+	r := &WebhookV2Resp{}
+	if err := json.Unmarshal(resp, r); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// FailedEvents: This endpoint retrieves the events which could not be delivered to a web-hook.
+// doc: https://developer.revolut.com/docs/business/get-failed-webhook-events
+func (w *WebhookV2Service) FailedEvents(id string, failedEventReq *FailedWebhookEventReq) ([]*FailedWebhookEvent, error) {
+	if w.err != nil {
+		return nil, w.err
+	}
+
+	params := url.Values{}
+	if failedEventReq != nil {
+		if failedEventReq.Limit != 0 {
+			params.Add("limit", fmt.Sprintf("%d", failedEventReq.Limit))
+		}
+		if !failedEventReq.CreatedBefore.IsZero() {
+			params.Add("created_before", failedEventReq.CreatedBefore.Format(time.RFC3339))
+		}
+	}
+
+	resp, statusCode, err := request.New(request.Config{
+		Method:      http.MethodGet,
+		Url:         fmt.Sprintf("https://b2b.revolut.com/api/2.0/webhooks/%s/failed-events?%s", id, params.Encode()),
+		AccessToken: w.accessToken,
+		Sandbox:     w.sandbox,
+		Domain:      w.domain,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if statusCode != http.StatusOK {
+		return nil, errors.New(string(resp))
+	}
+
+	r := []*FailedWebhookEvent{}
+	if err := json.Unmarshal(resp, &r); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
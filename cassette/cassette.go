@@ -0,0 +1,296 @@
+// Package cassette records the HTTP interactions of the API clients into cassette files
+// and replays them, so tests recorded once against the sandbox run offline.
+//
+// The Recorder and the Player are http.RoundTrippers plugged into the request packages:
+//
+//	request.Transport = cassette.NewRecorder("testdata/payments.json")
+package cassette
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/adless-tech/go-revolut/redact"
+)
+
+// ErrUnmatched is returned by the Player for a request which was not recorded.
+var ErrUnmatched = errors.New("cassette: request was not recorded")
+
+type Request struct {
+	Method string `json:"method"`
+	// the path of the url, the host is ignored so a cassette recorded in the sandbox replays anywhere
+	Path   string      `json:"path"`
+	Query  string      `json:"query,omitempty"`
+	Header http.Header `json:"header,omitempty"`
+	Body   string      `json:"body,omitempty"`
+}
+
+type Response struct {
+	Status int         `json:"status"`
+	Header http.Header `json:"header,omitempty"`
+	Body   string      `json:"body,omitempty"`
+}
+
+// Interaction is a request and its response.
+type Interaction struct {
+	Request  Request  `json:"request"`
+	Response Response `json:"response"`
+}
+
+// Cassette is the content of a cassette file.
+type Cassette struct {
+	Interactions []*Interaction `json:"interactions"`
+}
+
+// Load reads a cassette file.
+func Load(path string) (*Cassette, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Cassette{}
+	if err := json.Unmarshal(b, c); err != nil {
+		return nil, fmt.Errorf("cassette: %s: %w", path, err)
+	}
+
+	return c, nil
+}
+
+// Save writes the cassette file, creating its directory.
+func (c *Cassette) Save(path string) error {
+	b, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(path, b, 0644)
+}
+
+// Recorder is an http.RoundTripper sending the requests and recording them with their
+// responses, redacted by redact.Body. The cassette is written by Save.
+type Recorder struct {
+	// the transport sending the requests, nil uses http.DefaultTransport
+	Transport http.RoundTripper
+
+	path     string
+	mu       sync.Mutex
+	cassette Cassette
+}
+
+func NewRecorder(path string) *Recorder {
+	return &Recorder{
+		path: path,
+	}
+}
+
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	body, err := readBody(req)
+	if err != nil {
+		return nil, err
+	}
+
+	out := req.Clone(req.Context())
+	out.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	transport := r.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+
+	resp, err := transport.RoundTrip(out)
+	if err != nil {
+		return nil, err
+	}
+
+	respBody, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = ioutil.NopCloser(bytes.NewReader(respBody))
+
+	// the length changes with the redaction and the date would change every recording
+	responseHeader := redact.Header(resp.Header)
+	responseHeader.Del("Content-Length")
+	responseHeader.Del("Date")
+
+	interaction := &Interaction{
+		Request: Request{
+			Method: req.Method,
+			Path:   req.URL.Path,
+			Query:  req.URL.RawQuery,
+			Header: redact.Header(req.Header),
+			Body:   string(redact.Body(body)),
+		},
+		Response: Response{
+			Status: resp.StatusCode,
+			Header: responseHeader,
+			Body:   string(redact.Body(respBody)),
+		},
+	}
+
+	r.mu.Lock()
+	r.cassette.Interactions = append(r.cassette.Interactions, interaction)
+	r.mu.Unlock()
+
+	return resp, nil
+}
+
+// Save writes the recorded interactions to the cassette file.
+func (r *Recorder) Save() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.cassette.Save(r.path)
+}
+
+// Player is an http.RoundTripper answering the requests with the responses of a cassette.
+// A request is matched by its method, path, query and body to the first recorded interaction
+// not replayed yet, an unmatched request fails with ErrUnmatched. The request body is redacted
+// like the recorded one before matching.
+type Player struct {
+	mu        sync.Mutex
+	cassette  *Cassette
+	used      []bool
+	unmatched []string
+}
+
+func NewPlayer(path string) (*Player, error) {
+	c, err := Load(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Player{
+		cassette: c,
+		used:     make([]bool, len(c.Interactions)),
+	}, nil
+}
+
+func (p *Player) RoundTrip(req *http.Request) (*http.Response, error) {
+	body, err := readBody(req)
+	if err != nil {
+		return nil, err
+	}
+
+	query := normaliseQuery(req.URL.RawQuery)
+	normalisedBody := normaliseBody(redact.Body(body))
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for i, interaction := range p.cassette.Interactions {
+		recorded := interaction.Request
+		if p.used[i] || recorded.Method != req.Method || recorded.Path != req.URL.Path ||
+			normaliseQuery(recorded.Query) != query || normaliseBody([]byte(recorded.Body)) != normalisedBody {
+			continue
+		}
+		p.used[i] = true
+
+		header := http.Header{}
+		for k, v := range interaction.Response.Header {
+			header[k] = v
+		}
+
+		return &http.Response{
+			Status:        fmt.Sprintf("%d %s", interaction.Response.Status, http.StatusText(interaction.Response.Status)),
+			StatusCode:    interaction.Response.Status,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        header,
+			Body:          ioutil.NopCloser(strings.NewReader(interaction.Response.Body)),
+			ContentLength: int64(len(interaction.Response.Body)),
+			Request:       req,
+		}, nil
+	}
+
+	call := fmt.Sprintf("%s %s", req.Method, req.URL.Path)
+	if query != "" {
+		call += "?" + query
+	}
+	if normalisedBody != "" {
+		call += " " + normalisedBody
+	}
+	p.unmatched = append(p.unmatched, call)
+
+	return nil, fmt.Errorf("%w: %s", ErrUnmatched, call)
+}
+
+// Unmatched returns the requests which were not recorded.
+func (p *Player) Unmatched() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return append([]string{}, p.unmatched...)
+}
+
+// Unused returns the recorded interactions which were not replayed.
+func (p *Player) Unused() []*Interaction {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	unused := []*Interaction{}
+	for i, interaction := range p.cassette.Interactions {
+		if !p.used[i] {
+			unused = append(unused, interaction)
+		}
+	}
+
+	return unused
+}
+
+func readBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil {
+		return []byte{}, nil
+	}
+	defer req.Body.Close()
+
+	return ioutil.ReadAll(req.Body)
+}
+
+func normaliseQuery(rawQuery string) string {
+	values, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return rawQuery
+	}
+
+	return values.Encode()
+}
+
+// normaliseBody returns the body with sorted JSON keys or form values, so equal bodies compare equal.
+func normaliseBody(body []byte) string {
+	body = bytes.TrimSpace(body)
+	if len(body) == 0 {
+		return ""
+	}
+
+	var v interface{}
+	d := json.NewDecoder(bytes.NewReader(body))
+	d.UseNumber()
+	if err := d.Decode(&v); err == nil {
+		if b, err := json.Marshal(v); err == nil {
+			return string(b)
+		}
+	}
+
+	if values, err := url.ParseQuery(string(body)); err == nil && strings.Contains(string(body), "=") {
+		return values.Encode()
+	}
+
+	return string(body)
+}
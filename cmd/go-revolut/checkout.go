@@ -0,0 +1,159 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"html/template"
+	"log"
+	"net/http"
+	"net/url"
+	"time"
+
+	merchant "github.com/adless-tech/go-revolut/merchant/1.0"
+)
+
+var checkoutPage = template.Must(template.New("checkout").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Revolut Checkout test</title>
+<script src="{{.EmbedJs}}"></script>
+<style>
+body { font-family: sans-serif; max-width: 40em; margin: 2em auto; }
+pre { background: #f4f4f4; padding: 1em; overflow: auto; }
+</style>
+</head>
+<body>
+<h1>{{.Order.Description}}</h1>
+<p>Order <code>{{.Order.Id}}</code>, {{.Order.OrderAmount.Value}} {{.Order.OrderAmount.Currency}} in {{.Mode}} mode</p>
+<button id="pay">Pay</button>
+<p id="message"></p>
+<script>
+RevolutCheckout({{.Order.PublicId}}, {{.Mode}}).then(function (instance) {
+	document.getElementById("pay").addEventListener("click", function () {
+		instance.payWithPopup({
+			email: {{.Order.Email}},
+			onSuccess: function () { location.href = {{.ResultUrl}}; },
+			onError: function (message) { document.getElementById("message").textContent = "Error: " + message; location.href = {{.ResultUrl}}; },
+			onCancel: function () { document.getElementById("message").textContent = "Cancelled"; }
+		});
+	});
+});
+</script>
+</body>
+</html>
+`))
+
+var checkoutResultPage = template.Must(template.New("result").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Revolut Checkout result</title>
+<style>
+body { font-family: sans-serif; max-width: 40em; margin: 2em auto; }
+pre { background: #f4f4f4; padding: 1em; overflow: auto; }
+td, th { padding: 0 1em 0 0; text-align: left; }
+</style>
+</head>
+<body>
+<h1>Order {{.Order.State}}</h1>
+<p>Order <code>{{.Order.Id}}</code>, {{.Order.OrderAmount.Value}} {{.Order.OrderAmount.Currency}}</p>
+{{if .Order.Payments}}
+<table>
+<tr><th>Payment</th><th>State</th><th>Amount</th><th>Failure reason</th></tr>
+{{range .Order.Payments}}<tr><td>{{.Type}}</td><td>{{.State}}</td><td>{{.Amount.Value}} {{.Amount.Currency}}</td><td>{{.FailureReason}}</td></tr>
+{{end}}</table>
+{{end}}
+<p><a href="{{.ResultUrl}}">Refresh</a> · <a href="/">New order</a></p>
+<pre>{{.Json}}</pre>
+</body>
+</html>
+`))
+
+func checkoutServe(args []string) error {
+	fs := flag.NewFlagSet("checkout serve", flag.ExitOnError)
+	client, sandbox := merchantFlags(fs)
+	addr := fs.String("addr", "localhost:8080", "the address the page is served on")
+	amount := fs.Int("amount", 100, "the minor amount of the orders")
+	currency := fs.String("currency", "GBP", "the currency of the orders")
+	description := fs.String("description", "Checkout test", "the description of the orders")
+	email := fs.String("email", "", "the customer e-mail")
+	manual := fs.Bool("manual", false, "create the orders in manual capture mode")
+	fs.Parse(args)
+
+	mC, err := client()
+	if err != nil {
+		return err
+	}
+
+	mode, embedJs := "prod", "https://merchant.revolut.com/embed.js"
+	if *sandbox {
+		mode, embedJs = "sandbox", "https://sandbox-merchant.revolut.com/embed.js"
+	}
+
+	resultUrl := func(order *merchant.OrderResp) string {
+		return "/result?" + url.Values{"order": {order.Id}}.Encode()
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/" {
+			http.NotFound(w, r)
+			return
+		}
+
+		req := &merchant.OrderReq{
+			Amount:        *amount,
+			Currency:      *currency,
+			Description:   *description,
+			CustomerEmail: *email,
+		}
+		if *manual {
+			req.CaptureMode = merchant.CaptureMode_MANUAL
+		}
+
+		order, err := mC.Order().Create(req, "")
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+		log.Printf("created order %s", order.Id)
+
+		if err := checkoutPage.Execute(w, map[string]interface{}{
+			"Order":     order,
+			"Mode":      mode,
+			"EmbedJs":   embedJs,
+			"ResultUrl": resultUrl(order),
+		}); err != nil {
+			log.Println(err)
+		}
+	})
+	mux.HandleFunc("/result", func(w http.ResponseWriter, r *http.Request) {
+		order, err := mC.Order().WithId(r.URL.Query().Get("order"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+		log.Printf("order %s is %s", order.Id, order.State)
+
+		b, _ := json.MarshalIndent(order, "", "  ")
+		if err := checkoutResultPage.Execute(w, map[string]interface{}{
+			"Order":     order,
+			"Json":      string(b),
+			"ResultUrl": resultUrl(order),
+		}); err != nil {
+			log.Println(err)
+		}
+	})
+
+	fmt.Printf("serving the checkout page on http://%s in %s mode\n", *addr, mode)
+	srv := &http.Server{
+		Addr:         *addr,
+		Handler:      mux,
+		ReadTimeout:  10 * time.Second,
+		WriteTimeout: 30 * time.Second,
+	}
+
+	return srv.ListenAndServe()
+}
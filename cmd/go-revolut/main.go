@@ -2,184 +2,46 @@ package main
 
 import (
 	"fmt"
-
-	merchant "github.com/adless-tech/go-revolut/merchant/1.0"
+	"os"
+	"sort"
 )
 
-func main() {
-	//clientId := "pOoEBEmp8CwpBDgf3opC7aPnSe9OaSCC-fvvoti_RJU"
-	//issuer := "webhook.site"
-	//privateKeyFilename := "privatekey.pem"
-	//sandbox := true
-	//refreshToken := "oa_sand_mYSDtsl9SXjEEOy7maxO_ISrAOeqji_Eo30y6GSCRnc"
-	//
-	//privateKeyFile, err := ioutil.ReadFile(privateKeyFilename)
-	//if err != nil {
-	//	panic(err)
-	//}
-	//
-	//privateKey, err := jwt.ParseRSAPrivateKeyFromPEM(privateKeyFile)
-	//if err != nil {
-	//	panic(err)
-	//}
-	//
-	//bC, err := business.NewClient(clientId, refreshToken, privateKey, issuer, sandbox)
-	//if err != nil {
-	//	panic(err)
-	//}
-	//fmt.Println("\n--- ACCOUNTS ---")
-	//
-	//a := bC.Account()
-	//
-	//fmt.Println("\nList of all accounts:")
-	//
-	//accounts, err := a.List()
-	//if err != nil {
-	//	panic(err)
-	//}
-	//
-	//for _, account := range accounts {
-	//	fmt.Println(account)
-	//}
-	//
-	//fmt.Println("\nGet AccountService by id:")
-	//
-	//account, err := a.WithId(accounts[0].Id)
-	//if err != nil {
-	//	panic(err)
-	//}
-	//
-	//fmt.Println(account)
-	//
-	//fmt.Println("\nGet AccountService detail by id:")
-	//fmt.Println("todo")
-	////da, err := account.GetAccountDetail(accounts[0].Id)
-	////if err != nil {
-	////	panic(err)
-	////}
-	////
-	////fmt.Println(da)
-	//
-	//fmt.Println("\n--- COUNTERARTIES ---")
-	//cp := bC.Counterparty()
-	//
-	//fmt.Println("\nCreate revolut counterparty:")
-	//revolutCounterparty, err := cp.AddRevolut(&business.RevolutCounterpartyReq{
-	//	ProfileType: business.CounterpartyProfileType_PERSONAL,
-	//	Name:        "John Smith",
-	//	Phone:       "+4412345678900",
-	//})
-	//if err != nil {
-	//	panic(err)
-	//}
-	//fmt.Println(revolutCounterparty)
-	//fmt.Println("\nGet CounterpartyService by id:")
-	//revolutCounterparty, err = cp.WithId(revolutCounterparty.Id)
-	//if err != nil {
-	//	panic(err)
-	//}
-	//fmt.Println(revolutCounterparty)
-	//
-	//fmt.Println("\nDelete CounterpartyService by id:")
-	//err = cp.Delete(revolutCounterparty.Id)
-	//if err != nil {
-	//	panic(err)
-	//}
-	//fmt.Println(revolutCounterparty)
-	//
-	//fmt.Println("\nCreate non-revolut counterparty:")
-	//nonRevolutCounterparty, err := cp.AddNonRevolut(&business.NonRevolutCounterpartyReq{
-	//	CompanyName: "John Smith Co.",
-	//	BankCountry: "GB",
-	//	Currency:    "GBP",
-	//	AccountNo:   "12345678",
-	//	SortCode:    "223344",
-	//	Email:       "john@smith.co",
-	//	Phone:       "+447771234455",
-	//	Address: business.NonRevolutCounterpartyReqAddress{
-	//		StreetLine1: "1 Canada Square",
-	//		StreetLine2: "Canary Wharf",
-	//		Region:      "East End",
-	//		Postcode:    "E115AB",
-	//		City:        "London",
-	//		Country:     "GB",
-	//	},
-	//})
-	//if err != nil {
-	//	panic(err)
-	//}
-	//fmt.Println(nonRevolutCounterparty)
-	//fmt.Println("\nGet CounterpartyService by id:")
-	//nonRevolutCounterparty, err = cp.WithId(nonRevolutCounterparty.Id)
-	//if err != nil {
-	//	panic(err)
-	//}
-	//fmt.Println(nonRevolutCounterparty)
-	//
-	//fmt.Println("\nDelete CounterpartyService by id:")
-	//err = cp.Delete(nonRevolutCounterparty.Id)
-	//if err != nil {
-	//	panic(err)
-	//}
-	//fmt.Println(nonRevolutCounterparty)
-	//
-	//fmt.Println("\nList of all counterparties:")
-	//counterparties, err := cp.List()
-	//if err != nil {
-	//	panic(err)
-	//}
-	//for _, counterparty := range counterparties {
-	//	fmt.Println(counterparty)
-	//}
-	//
-	//fmt.Println("\n--- EXCHANGE ---")
-	//rate, err := bC.Exchange().Rate(&business.ExchangeRateReq{
-	//	From:   "USD",
-	//	To:     "EUR",
-	//	Amount: 100,
-	//})
-	//if err != nil {
-	//	panic(err)
-	//}
-	//fmt.Println(rate)
-
-	apiKey := "Yo1ysB60s8GHEFmrConnG450iK6diAw7cDf-wExzbMdqKi8buyWB5CSEETw6hI_Z"
+// commands maps "<group> <command>" to its implementation, which gets the remaining arguments
+var commands = map[string]func(args []string) error{
+	"webhook simulate": webhookSimulate,
+	"orders list":      ordersList,
+	"checkout serve":   checkoutServe,
+}
 
-	order, err := merchant.NewClient(apiKey).Order().Create(&merchant.OrderReq{
-		Amount:             200,
-		CaptureMode:        merchant.CaptureMode_MANUAL,
-		MerchantOrderID:    "00122",
-		CustomerEmail:      "rysavy.vladan@gmail.com",
-		Description:        "description",
-		Currency:           "GBP",
-		SettlementCurrency: "USD",
-		MerchantCustomerID: "sally01",
-	})
-	if err != nil {
-		panic(err)
+func main() {
+	if len(os.Args) < 3 {
+		printUsage()
+		os.Exit(2)
 	}
-	fmt.Println(order)
 
-	webhooks, err := merchant.NewClient(apiKey).Webhook().List()
-	if err != nil {
-		panic(err)
+	command, ok := commands[os.Args[1]+" "+os.Args[2]]
+	if !ok {
+		printUsage()
+		os.Exit(2)
 	}
-	for _, webhook := range webhooks {
-		fmt.Println(webhook)
+
+	if err := command(os.Args[3:]); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
 	}
+}
 
-	// Acme Corporation
+func printUsage() {
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
 
-	err = merchant.NewClient(apiKey).Webhook().Set(&merchant.WebhookUrl{Url: "https://webhook.site/#!/9d886b86-2880-493f-abf9-2b0a04a77df5"})
-	if err != nil {
-		panic(err)
+	fmt.Fprintln(os.Stderr, "usage: go-revolut <command> [flags]")
+	fmt.Fprintln(os.Stderr, "\ncommands:")
+	for _, name := range names {
+		fmt.Fprintf(os.Stderr, "  %s\n", name)
 	}
-	//
-	//webhooks, err = mC.Webhook().List()
-	//if err != nil {
-	//	panic(err)
-	//}
-	//for _, webhook := range webhooks {
-	//	fmt.Println(webhook)
-	//}
+	fmt.Fprintln(os.Stderr, "\nrun go-revolut <command> -h for the flags of a command")
 }
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	merchant "github.com/adless-tech/go-revolut/merchant/1.0"
+)
+
+// merchantFlags registers the flags every merchant command needs, it returns the client constructor
+// and the sandbox flag, both read after fs is parsed
+func merchantFlags(fs *flag.FlagSet) (func() (*merchant.Client, error), *bool) {
+	apiKey := fs.String("api-key", os.Getenv("REVOLUT_API_KEY"), "the merchant API key, default is $REVOLUT_API_KEY")
+	sandbox := fs.Bool("sandbox", false, "use the sandbox environment")
+
+	return func() (*merchant.Client, error) {
+		if *apiKey == "" {
+			return nil, errors.New("missing -api-key")
+		}
+		if *sandbox {
+			return merchant.NewSandboxClient(*apiKey), nil
+		}
+		return merchant.NewProductionClient(*apiKey), nil
+	}, sandbox
+}
+
+func ordersList(args []string) error {
+	fs := flag.NewFlagSet("orders list", flag.ExitOnError)
+	client, _ := merchantFlags(fs)
+	email := fs.String("email", "", "the customer e-mail")
+	ref := fs.String("ref", "", "the merchant order ID")
+	customer := fs.String("customer", "", "the customer ID")
+	states := fs.String("state", "", "comma separated order states, e.g. AUTHORISED,COMPLETED")
+	from := fs.String("from", "", "created from, as 2006-01-02 or RFC 3339")
+	to := fs.String("to", "", "created to, as 2006-01-02 including the whole day or RFC 3339")
+	max := fs.Int("max", 0, "the maximum number of orders printed, 0 is all")
+	fs.Parse(args)
+
+	mC, err := client()
+	if err != nil {
+		return err
+	}
+
+	req := &merchant.OrderListReq{
+		Email:               *email,
+		MerchantOrderExtRef: *ref,
+		CustomerId:          *customer,
+	}
+	if *states != "" {
+		for _, state := range strings.Split(*states, ",") {
+			req.State = append(req.State, merchant.OrderState(strings.ToUpper(strings.TrimSpace(state))))
+		}
+	}
+	if req.FromCreatedDate, err = parseDate(*from, false); err != nil {
+		return err
+	}
+	if req.ToCreatedDate, err = parseDate(*to, true); err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tSTATE\tAMOUNT\tCURRENCY\tEMAIL\tMERCHANT ORDER ID\tCREATED")
+
+	it := mC.Order().Iterate(req)
+	for n := 0; (*max == 0 || n < *max) && it.Next(); n++ {
+		order := it.Order()
+		fmt.Fprintf(w, "%s\t%s\t%d\t%s\t%s\t%s\t%s\n",
+			order.Id, order.State, order.OrderAmount.Value, order.OrderAmount.Currency,
+			order.Email, order.MerchantOrderExtRef,
+			time.Unix(0, order.CreatedDate*int64(time.Millisecond)).UTC().Format(time.RFC3339))
+	}
+	w.Flush()
+
+	return it.Err()
+}
+
+// parseDate parses a date or an RFC 3339 time, a date is its midnight or with endOfDay the midnight after it
+func parseDate(s string, endOfDay bool) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse("2006-01-02", s); err == nil {
+		if endOfDay {
+			return t.AddDate(0, 0, 1), nil
+		}
+		return t, nil
+	}
+
+	return time.Parse(time.RFC3339, s)
+}
@@ -0,0 +1,254 @@
+package main
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	business "github.com/adless-tech/go-revolut/business/1.0"
+	merchant "github.com/adless-tech/go-revolut/merchant/1.0"
+	"github.com/adless-tech/go-revolut/webhook"
+)
+
+type scenario struct {
+	Steps []scenarioStep `json:"steps"`
+}
+
+type scenarioStep struct {
+	// the event name, e.g. TransactionCreated or ORDER_COMPLETED
+	Event string `json:"event"`
+	// an optional delay before the step, default is the -delay flag
+	Delay string `json:"delay,omitempty"`
+	// the fields set on the generated payload by their dotted path, e.g. data.new_state
+	Fields map[string]interface{} `json:"fields,omitempty"`
+}
+
+var scenarios = map[string]scenario{
+	"payment": {Steps: []scenarioStep{
+		{Event: string(business.WebhookEvent_TRANSACTION_CREATED)},
+		{Event: string(business.WebhookEvent_TRANSACTION_STATE_CHANGED)},
+	}},
+	"payment-declined": {Steps: []scenarioStep{
+		{Event: string(business.WebhookEvent_TRANSACTION_CREATED)},
+		{Event: string(business.WebhookEvent_TRANSACTION_STATE_CHANGED), Fields: map[string]interface{}{
+			"data.new_state": string(business.PaymentState_DECLINE),
+		}},
+	}},
+	"order": {Steps: []scenarioStep{
+		{Event: string(merchant.WebhookEvent_ORDER_AUTHORISED)},
+		{Event: string(merchant.WebhookEvent_ORDER_COMPLETED)},
+	}},
+	"order-cancelled": {Steps: []scenarioStep{
+		{Event: string(merchant.WebhookEvent_ORDER_AUTHORISED)},
+		{Event: string(merchant.WebhookEvent_ORDER_CANCELLED)},
+	}},
+	"order-declined": {Steps: []scenarioStep{
+		{Event: string(merchant.WebhookEvent_ORDER_PAYMENT_DECLINED)},
+	}},
+}
+
+// fieldFlags collects the repeated -set flags
+type fieldFlags map[string]interface{}
+
+func (f fieldFlags) String() string {
+	return fmt.Sprint(map[string]interface{}(f))
+}
+
+func (f fieldFlags) Set(s string) error {
+	kv := strings.SplitN(s, "=", 2)
+	if len(kv) != 2 || kv[0] == "" {
+		return errors.New("expected path=value")
+	}
+
+	var value interface{}
+	if err := json.Unmarshal([]byte(kv[1]), &value); err != nil {
+		value = kv[1]
+	}
+	f[kv[0]] = value
+
+	return nil
+}
+
+func webhookSimulate(args []string) error {
+	fields := fieldFlags{}
+
+	fs := flag.NewFlagSet("webhook simulate", flag.ExitOnError)
+	target := fs.String("url", "http://localhost:8080/webhook", "the url the events are posted to")
+	secret := fs.String("secret", "", "the signing secret, the events are not signed without it")
+	name := fs.String("scenario", "payment", "the built-in scenario: payment, payment-declined, order, order-cancelled or order-declined")
+	file := fs.String("file", "", "a JSON scenario file, overrides -scenario")
+	delay := fs.Duration("delay", time.Second, "the delay between the events")
+	fs.Var(fields, "set", "a field of every event as path=value, e.g. data.reference=Invoice, can be repeated")
+	fs.Parse(args)
+
+	sc, ok := scenarios[*name]
+	if *file != "" {
+		b, err := ioutil.ReadFile(*file)
+		if err != nil {
+			return err
+		}
+		if err := json.Unmarshal(b, &sc); err != nil {
+			return err
+		}
+	} else if !ok {
+		return fmt.Errorf("unknown scenario %q", *name)
+	}
+
+	ids := map[string]string{
+		"transaction": newUuid(),
+		"request":     newUuid(),
+		"leg":         newUuid(),
+		"account":     newUuid(),
+		"order":       newUuid(),
+		"order_ref":   "order-" + newUuid()[:8],
+	}
+	state := string(business.PaymentState_PENDING)
+
+	for i, step := range sc.Steps {
+		if i > 0 {
+			d := *delay
+			if step.Delay != "" {
+				var err error
+				if d, err = time.ParseDuration(step.Delay); err != nil {
+					return err
+				}
+			}
+			time.Sleep(d)
+		}
+
+		payload, err := eventPayload(step.Event, ids, state)
+		if err != nil {
+			return err
+		}
+		for path, value := range step.Fields {
+			setField(payload, path, value)
+		}
+		for path, value := range fields {
+			setField(payload, path, value)
+		}
+
+		// the next state change starts where this event ended
+		if data, ok := payload["data"].(map[string]interface{}); ok {
+			if s, ok := data["new_state"].(string); ok {
+				state = s
+			} else if s, ok := data["state"].(string); ok {
+				state = s
+			}
+		}
+
+		status, err := postEvent(*target, *secret, payload)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%s -> %s\n", step.Event, status)
+	}
+
+	return nil
+}
+
+// eventPayload generates a realistic payload of the event
+func eventPayload(event string, ids map[string]string, state string) (map[string]interface{}, error) {
+	now := time.Now().UTC().Format(time.RFC3339Nano)
+
+	switch business.WebhookEvent(event) {
+	case business.WebhookEvent_TRANSACTION_CREATED:
+		return map[string]interface{}{
+			"event":     event,
+			"timestamp": now,
+			"data": map[string]interface{}{
+				"id":         ids["transaction"],
+				"type":       string(business.PaymentType_TRANSFER),
+				"request_id": ids["request"],
+				"state":      state,
+				"created_at": now,
+				"updated_at": now,
+				"reference":  "Simulated payment",
+				"legs": []interface{}{
+					map[string]interface{}{
+						"leg_id":      ids["leg"],
+						"account_id":  ids["account"],
+						"amount":      -100,
+						"currency":    "GBP",
+						"description": "To Acme Corporation",
+					},
+				},
+			},
+		}, nil
+
+	case business.WebhookEvent_TRANSACTION_STATE_CHANGED:
+		return map[string]interface{}{
+			"event":     event,
+			"timestamp": now,
+			"data": map[string]interface{}{
+				"id":        ids["transaction"],
+				"old_state": state,
+				"new_state": string(business.PaymentState_COMPLETE),
+			},
+		}, nil
+	}
+
+	if strings.HasPrefix(event, "ORDER_") || strings.HasPrefix(event, "PAYOUT_") {
+		return map[string]interface{}{
+			"event":                  event,
+			"order_id":               ids["order"],
+			"merchant_order_ext_ref": ids["order_ref"],
+		}, nil
+	}
+
+	return nil, fmt.Errorf("unknown event %q", event)
+}
+
+// setField sets the value at the dotted path, creating the missing objects
+func setField(payload map[string]interface{}, path string, value interface{}) {
+	keys := strings.Split(path, ".")
+	m := payload
+	for _, key := range keys[:len(keys)-1] {
+		next, ok := m[key].(map[string]interface{})
+		if !ok {
+			next = map[string]interface{}{}
+			m[key] = next
+		}
+		m = next
+	}
+	m[keys[len(keys)-1]] = value
+}
+
+func postEvent(target, secret string, payload map[string]interface{}) (string, error) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, target, bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if secret != "" {
+		webhook.SignRequest(req, secret, time.Now(), body)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	return resp.Status, nil
+}
+
+func newUuid() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
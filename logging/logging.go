@@ -0,0 +1,129 @@
+// Package logging emits a structured record for every call of the API clients.
+//
+//	request.Logger = logging.NewLogger(logging.Text(log.New(os.Stderr, "", log.LstdFlags)))
+package logging
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/adless-tech/go-revolut/redact"
+)
+
+// maxRequestIds bounds the request IDs remembered for counting the retries.
+const maxRequestIds = 10000
+
+// Record is the record of an API call.
+type Record struct {
+	Method string `json:"method"`
+	// the path of the url, without the query
+	Path string `json:"path"`
+	// the response status, zero when no response was received
+	Status  int           `json:"status,omitempty"`
+	Latency time.Duration `json:"-"`
+	// the ID making the call idempotent, request_id of the business API or the x-idempotency-key of the merchant API
+	RequestId string `json:"request_id,omitempty"`
+	// how many times the call with the same RequestId was sent before
+	Retry int `json:"retry"`
+	// the error of a call which received no response, its text is redacted when written
+	Err error `json:"-"`
+	// the redacted bodies, only when the Logger logs the bodies
+	RequestBody  string `json:"request_body,omitempty"`
+	ResponseBody string `json:"response_body,omitempty"`
+}
+
+// String formats the record as key=value pairs.
+func (r *Record) String() string {
+	s := fmt.Sprintf("method=%s path=%s status=%d latency=%s", r.Method, r.Path, r.Status, r.Latency)
+	if r.RequestId != "" {
+		s += fmt.Sprintf(" request_id=%q", r.RequestId)
+	}
+	s += fmt.Sprintf(" retry=%d", r.Retry)
+	if r.Err != nil {
+		s += fmt.Sprintf(" error=%q", redact.Text(r.Err.Error()))
+	}
+	if r.RequestBody != "" {
+		s += fmt.Sprintf(" request_body=%q", r.RequestBody)
+	}
+	if r.ResponseBody != "" {
+		s += fmt.Sprintf(" response_body=%q", r.ResponseBody)
+	}
+
+	return s
+}
+
+// Logger builds the records of the calls and passes them to a function writing them.
+type Logger struct {
+	// log the request and the response bodies, redacted by redact.Body
+	Bodies bool
+
+	write    func(record *Record)
+	mu       sync.Mutex
+	attempts map[string]int
+}
+
+func NewLogger(write func(record *Record)) *Logger {
+	return &Logger{
+		write:    write,
+		attempts: map[string]int{},
+	}
+}
+
+// Log records a call, it is called by the request packages.
+func (l *Logger) Log(record *Record, requestBody, responseBody []byte) {
+	if record.RequestId != "" {
+		l.mu.Lock()
+		if len(l.attempts) >= maxRequestIds {
+			l.attempts = map[string]int{}
+		}
+		record.Retry = l.attempts[record.RequestId]
+		l.attempts[record.RequestId]++
+		l.mu.Unlock()
+	}
+
+	if l.Bodies {
+		record.RequestBody = string(redact.Body(requestBody))
+		record.ResponseBody = string(redact.Body(responseBody))
+	}
+
+	l.write(record)
+}
+
+// Text writes the records as key=value lines to l.
+func Text(l *log.Logger) func(record *Record) {
+	return func(record *Record) {
+		l.Println(record.String())
+	}
+}
+
+// JSON writes the records as JSON lines to w.
+func JSON(w io.Writer) func(record *Record) {
+	var mu sync.Mutex
+
+	return func(record *Record) {
+		line := struct {
+			*Record
+			LatencyMs float64 `json:"latency_ms"`
+			Error     string  `json:"error,omitempty"`
+		}{
+			Record:    record,
+			LatencyMs: float64(record.Latency) / float64(time.Millisecond),
+		}
+		if record.Err != nil {
+			line.Error = redact.Text(record.Err.Error())
+		}
+
+		b, err := json.Marshal(line)
+		if err != nil {
+			return
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+		w.Write(append(b, '\n'))
+	}
+}
@@ -0,0 +1,61 @@
+package merchant
+
+import (
+	"time"
+)
+
+// API is the interface of Client, code depending on it can be tested with merchanttest.MockClient.
+type API interface {
+	Order() OrderAPI
+	Webhook() WebhookAPI
+	Customer() CustomerAPI
+}
+
+// OrderAPI is the interface of OrderService. It holds the endpoints only, the guarded operations
+// CaptureAmount, CaptureOrder, CancelOrder and RefundOrder are functions over it, so they run with a mock too.
+type OrderAPI interface {
+	Create(orderReq *OrderReq, idempotencyKey string) (*OrderResp, error)
+	WithId(id string) (*OrderResp, error)
+	List(orderListReq *OrderListReq) ([]*OrderResp, error)
+	Iterate(orderListReq *OrderListReq) *OrderIterator
+	Update(id string, orderUpdateReq *OrderUpdateReq) (*OrderResp, error)
+	Confirm(id string) (*OrderResp, error)
+	ConfirmWithPaymentMethod(id string, confirmReq *ConfirmReq) (*OrderResp, error)
+	Capture(id string) (*OrderResp, error)
+	CaptureWithAmount(id string, amount int) (*OrderResp, error)
+	Cancel(id string) (*OrderResp, error)
+	Refund(id string, refundReq *RefundReq) (*RefundResp, error)
+	RefundWithIdempotencyKey(id string, refundReq *RefundReq, idempotencyKey string) (*RefundResp, error)
+}
+
+// WebhookAPI is the interface of WebhookService.
+type WebhookAPI interface {
+	Set(webhookReq *WebhookUrl) error
+	Create(webhookReq *WebhookReq) (*Webhook, error)
+	List() ([]*Webhook, error)
+	WithId(id string) (*Webhook, error)
+	Update(id string, webhookReq *WebhookReq) (*Webhook, error)
+	Delete(id string) error
+	RotateSigningSecret(id string, expirationPeriod time.Duration) (*Webhook, error)
+}
+
+// CustomerAPI is the interface of CustomerService.
+type CustomerAPI interface {
+	Create(req *CreateCustomerReq) (*CustomerResp, error)
+	List(limit, page int) ([]*CustomerResp, error)
+	Iterate(limit int) *CustomerIterator
+	WithId(id string) (*CustomerResp, error)
+	Update(id string, req *UpdateCustomerReq) (*CustomerResp, error)
+	Delete(id string) error
+	PaymentMethods(customerId string, onlyMerchant bool) ([]*CustomerPaymentMethod, error)
+	PaymentMethod(customerId, paymentMethodId string) (*CustomerPaymentMethod, error)
+	UpdatePaymentMethod(customerId, paymentMethodId string, savedFor SavedFor) (*CustomerPaymentMethod, error)
+	DeletePaymentMethod(customerId, paymentMethodId string) error
+}
+
+var (
+	_ API         = (*Client)(nil)
+	_ OrderAPI    = (*OrderService)(nil)
+	_ WebhookAPI  = (*WebhookService)(nil)
+	_ CustomerAPI = (*CustomerService)(nil)
+)
@@ -0,0 +1,200 @@
+package merchant
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/adless-tech/go-revolut/merchant/1.0/request"
+)
+
+type PaymentState string
+
+const (
+	PaymentState_PENDING                  PaymentState = "PENDING"
+	PaymentState_AUTHENTICATION_CHALLENGE PaymentState = "AUTHENTICATION_CHALLENGE"
+	PaymentState_AUTHENTICATION_VERIFIED  PaymentState = "AUTHENTICATION_VERIFIED"
+	PaymentState_AUTHORISATION_STARTED    PaymentState = "AUTHORISATION_STARTED"
+	PaymentState_AUTHORISATION_PASSED     PaymentState = "AUTHORISATION_PASSED"
+	PaymentState_AUTHORISED               PaymentState = "AUTHORISED"
+	PaymentState_CAPTURE_STARTED          PaymentState = "CAPTURE_STARTED"
+	PaymentState_CAPTURED                 PaymentState = "CAPTURED"
+	PaymentState_REFUND_VALIDATED         PaymentState = "REFUND_VALIDATED"
+	PaymentState_REFUND_STARTED           PaymentState = "REFUND_STARTED"
+	PaymentState_CANCELLATION_STARTED     PaymentState = "CANCELLATION_STARTED"
+	PaymentState_DECLINING                PaymentState = "DECLINING"
+	PaymentState_COMPLETING               PaymentState = "COMPLETING"
+	PaymentState_CANCELLING               PaymentState = "CANCELLING"
+	PaymentState_FAILING                  PaymentState = "FAILING"
+	PaymentState_COMPLETED                PaymentState = "COMPLETED"
+	PaymentState_DECLINED                 PaymentState = "DECLINED"
+	PaymentState_SOFT_DECLINED            PaymentState = "SOFT_DECLINED"
+	PaymentState_CANCELLED                PaymentState = "CANCELLED"
+	PaymentState_FAILED                   PaymentState = "FAILED"
+)
+
+// Captured reports whether the money of the payment was captured.
+func (s PaymentState) Captured() bool {
+	switch s {
+	case PaymentState_CAPTURE_STARTED, PaymentState_CAPTURED, PaymentState_COMPLETING, PaymentState_COMPLETED,
+		PaymentState_REFUND_VALIDATED, PaymentState_REFUND_STARTED:
+		return true
+	}
+	return false
+}
+
+// DefaultAuthorisationExpiry is the period after which Revolut cancels an uncaptured
+// authorisation when the order has no cancel_authorised_after.
+const DefaultAuthorisationExpiry = 7 * 24 * time.Hour
+
+var (
+	ErrNotManualCapture     = errors.New("merchant: order is not in manual capture mode")
+	ErrNotAuthorised        = errors.New("merchant: order is not authorised")
+	ErrAuthorisationExpired = errors.New("merchant: authorisation of the order expired")
+	ErrInvalidCaptureAmount = errors.New("merchant: invalid capture amount")
+)
+
+// CapturedAmount returns the minor amount captured by the payments of the order.
+func (o *OrderResp) CapturedAmount() int {
+	captured := 0
+	for _, payment := range o.Payments {
+		if payment.State.Captured() {
+			captured += payment.Amount.Value
+		}
+	}
+
+	return captured
+}
+
+// CapturableAmount returns the minor amount of the order which is not captured yet.
+func (o *OrderResp) CapturableAmount() int {
+	if !o.State.CanCapture() {
+		return 0
+	}
+
+	remaining := o.OrderAmount.Value - o.CapturedAmount()
+	if remaining < 0 {
+		return 0
+	}
+
+	return remaining
+}
+
+// AuthorisationExpiresAt returns the instant the authorisation of the order expires,
+// counted from the authorisation by cancel_authorised_after or DefaultAuthorisationExpiry.
+func (o *OrderResp) AuthorisationExpiresAt() (time.Time, error) {
+	authorisedAt := o.UpdatedDate
+	for _, payment := range o.Payments {
+		if payment.State == PaymentState_AUTHORISED && payment.UpdatedDate != 0 {
+			authorisedAt = payment.UpdatedDate
+		}
+	}
+
+	expiry := DefaultAuthorisationExpiry
+	if o.CancelAuthorisedAfter != "" {
+		var err error
+		if expiry, err = parseIsoDuration(o.CancelAuthorisedAfter); err != nil {
+			return time.Time{}, err
+		}
+	}
+
+	return time.Unix(0, authorisedAt*int64(time.Millisecond)).Add(expiry), nil
+}
+
+// CheckCapture reports why capturing amount of the order would fail, nil when it can be captured.
+func (o *OrderResp) CheckCapture(amount int, now time.Time) error {
+	if o.CaptureMode != CaptureMode_MANUAL {
+		return ErrNotManualCapture
+	}
+	if !o.State.CanCapture() {
+		return fmt.Errorf("%w: state is %s", ErrNotAuthorised, o.State)
+	}
+
+	expiresAt, err := o.AuthorisationExpiresAt()
+	if err != nil {
+		return err
+	}
+	if !now.Before(expiresAt) {
+		return fmt.Errorf("%w at %s", ErrAuthorisationExpired, expiresAt.Format(time.RFC3339))
+	}
+
+	if remaining := o.CapturableAmount(); amount <= 0 || amount > remaining {
+		return fmt.Errorf("%w: %d, capturable is %d", ErrInvalidCaptureAmount, amount, remaining)
+	}
+
+	return nil
+}
+
+// CaptureAmount captures the amount of an authorised order in manual capture mode.
+// The order is validated locally first, so an amount over the remaining
+// authorised amount or an expired authorisation fails without capturing.
+func CaptureAmount(orders OrderAPI, id string, amount int) (*OrderResp, error) {
+	order, err := orders.WithId(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := order.CheckCapture(amount, time.Now()); err != nil {
+		return nil, err
+	}
+
+	return orders.CaptureWithAmount(id, amount)
+}
+
+// CaptureWithAmount: Captures the amount of an order in manual capture mode.
+// The order is not checked, CaptureAmount is the guarded entry point.
+// doc: https://developer.revolut.com/docs/merchant/capture-order
+func (a *OrderService) CaptureWithAmount(id string, amount int) (*OrderResp, error) {
+	resp, statusCode, err := request.New(request.Config{
+		Method: http.MethodPost,
+		Url:    fmt.Sprintf("%s/api/1.0/orders/%s/capture", a.domain, id),
+		ApiKey: a.apiKey,
+		Body: struct {
+			// Minor amount
+			Amount int `json:"amount"`
+		}{Amount: amount},
+		ContentType: request.ContentType_APPLICATION_JSON,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if statusCode != http.StatusOK {
+		return nil, errors.New(fmt.Sprintf("%s [status: %d]", string(resp), statusCode))
+	}
+
+	r := &OrderResp{}
+	if err := json.Unmarshal(resp, r); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+var isoDurationRegexp = regexp.MustCompile(`^P(?:(\d+)W)?(?:(\d+)D)?(?:T(?:(\d+)H)?(?:(\d+)M)?(?:(\d+)S)?)?$`)
+
+// parseIsoDuration parses the ISO 8601 durations made of weeks, days, hours, minutes and seconds
+func parseIsoDuration(s string) (time.Duration, error) {
+	m := isoDurationRegexp.FindStringSubmatch(s)
+	if m == nil || s == "P" || s == "PT" {
+		return 0, fmt.Errorf("merchant: unsupported ISO 8601 duration %q", s)
+	}
+
+	units := []time.Duration{7 * 24 * time.Hour, 24 * time.Hour, time.Hour, time.Minute, time.Second}
+	var d time.Duration
+	for i, unit := range units {
+		if m[i+1] == "" {
+			continue
+		}
+		n, err := strconv.Atoi(m[i+1])
+		if err != nil {
+			return 0, err
+		}
+		d += time.Duration(n) * unit
+	}
+
+	return d, nil
+}
@@ -0,0 +1,246 @@
+package merchant
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/adless-tech/go-revolut/merchant/1.0/request"
+)
+
+type PaymentInitiator string
+
+const (
+	// the customer is present and pays
+	PaymentInitiator_CUSTOMER PaymentInitiator = "CUSTOMER"
+	// the merchant charges the customer who is not present
+	PaymentInitiator_MERCHANT PaymentInitiator = "MERCHANT"
+)
+
+type FailureReason string
+
+const (
+	FailureReason_INSUFFICIENT_FUNDS           FailureReason = "insufficient_funds"
+	FailureReason_DO_NOT_HONOUR                FailureReason = "do_not_honour"
+	FailureReason_EXPIRED_CARD                 FailureReason = "expired_card"
+	FailureReason_RESTRICTED_CARD              FailureReason = "restricted_card"
+	FailureReason_CARDHOLDER_NAME_MISSING      FailureReason = "cardholder_name_missing"
+	FailureReason_HIGH_RISK                    FailureReason = "high_risk"
+	FailureReason_TRANSACTION_NOT_ALLOWED      FailureReason = "transaction_not_allowed_for_cardholder"
+	FailureReason_THREE_DS_CHALLENGE_FAILED    FailureReason = "three_ds_challenge_failed"
+	FailureReason_THREE_DS_CHALLENGE_ABANDONED FailureReason = "three_ds_challenge_abandoned"
+	FailureReason_INVALID_CARD                 FailureReason = "invalid_card"
+	FailureReason_WITHDRAWAL_LIMIT_EXCEEDED    FailureReason = "withdrawal_limit_exceeded"
+)
+
+type ConfirmReq struct {
+	// ID of the saved payment method of the order customer
+	PaymentMethodId string `json:"payment_method_id,omitempty"`
+	// who initiates the payment, MERCHANT for a customer who is not present
+	Initiator PaymentInitiator `json:"initiator,omitempty"`
+}
+
+var ErrNoPaymentMethod = errors.New("merchant: customer has no payment method saved for the merchant")
+
+// DeclineError is returned when the payment of a charge is declined or failed.
+type DeclineError struct {
+	// the charged order
+	OrderId string
+	// DECLINED, SOFT_DECLINED or FAILED
+	State PaymentState
+	// the reason given by Revolut
+	FailureReason FailureReason
+}
+
+func (e *DeclineError) Error() string {
+	if e.FailureReason == "" {
+		return fmt.Sprintf("merchant: payment of order %s is %s", e.OrderId, e.State)
+	}
+	return fmt.Sprintf("merchant: payment of order %s is %s: %s", e.OrderId, e.State, e.FailureReason)
+}
+
+// Soft reports whether the decline may pass when the customer is present, e.g. after 3DS.
+func (e *DeclineError) Soft() bool {
+	return e.State == PaymentState_SOFT_DECLINED
+}
+
+// Final reports whether the payment will not change its state anymore, except by a refund.
+func (s PaymentState) Final() bool {
+	switch s {
+	case PaymentState_AUTHORISED, PaymentState_CAPTURED, PaymentState_COMPLETED,
+		PaymentState_DECLINED, PaymentState_SOFT_DECLINED, PaymentState_CANCELLED, PaymentState_FAILED:
+		return true
+	}
+	return false
+}
+
+// Declined reports whether the payment was declined or failed.
+func (s PaymentState) Declined() bool {
+	switch s {
+	case PaymentState_DECLINING, PaymentState_DECLINED, PaymentState_SOFT_DECLINED,
+		PaymentState_FAILING, PaymentState_FAILED:
+		return true
+	}
+	return false
+}
+
+// LastPayment returns the latest payment of the order or nil.
+func (o *OrderResp) LastPayment() *Payment {
+	var last *Payment
+	for i := range o.Payments {
+		if last == nil || o.Payments[i].CreatedDate >= last.CreatedDate {
+			last = &o.Payments[i]
+		}
+	}
+
+	return last
+}
+
+// Err returns a *DeclineError for a declined or failed payment, otherwise nil.
+func (p *Payment) Err(orderId string) error {
+	state, reason := p.State, p.FailureReason
+	if !state.Declined() && p.PaymentMethod.State.Declined() {
+		state = p.PaymentMethod.State
+	}
+	if reason == "" {
+		reason = p.PaymentMethod.FailureReason
+	}
+
+	if !state.Declined() {
+		return nil
+	}
+
+	switch state {
+	case PaymentState_DECLINING:
+		state = PaymentState_DECLINED
+	case PaymentState_FAILING:
+		state = PaymentState_FAILED
+	}
+
+	return &DeclineError{
+		OrderId:       orderId,
+		State:         state,
+		FailureReason: FailureReason(reason),
+	}
+}
+
+// ConfirmWithPaymentMethod: Use this request to pay an order of a customer with a saved payment method.
+// doc: https://developer.revolut.com/docs/merchant/confirm-order
+func (a *OrderService) ConfirmWithPaymentMethod(id string, confirmReq *ConfirmReq) (*OrderResp, error) {
+	resp, statusCode, err := request.New(request.Config{
+		Method:      http.MethodPost,
+		Url:         fmt.Sprintf("%s/api/1.0/orders/%s/confirm", a.domain, id),
+		ApiKey:      a.apiKey,
+		Body:        confirmReq,
+		ContentType: request.ContentType_APPLICATION_JSON,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if statusCode != http.StatusOK {
+		return nil, errors.New(fmt.Sprintf("%s [status: %d]", string(resp), statusCode))
+	}
+
+	r := &OrderResp{}
+	if err := json.Unmarshal(resp, r); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// Charger charges returning customers who are not present with their saved payment methods.
+type Charger struct {
+	orders    OrderAPI
+	customers CustomerAPI
+
+	// how long Charge waits for the payment to reach a final state, zero returns the confirmed order as is
+	Timeout time.Duration
+	// how often the order is checked while waiting, default is 2 seconds
+	PollInterval time.Duration
+}
+
+func NewCharger(orders OrderAPI, customers CustomerAPI) *Charger {
+	return &Charger{
+		orders:       orders,
+		customers:    customers,
+		PollInterval: 2 * time.Second,
+	}
+}
+
+// Charge creates the order for orderReq.CustomerID and confirms it as a merchant initiated payment.
+// An empty paymentMethodId picks the first payment method saved for the merchant.
+// A declined or failed payment is returned together with the order as a *DeclineError.
+func (c *Charger) Charge(orderReq *OrderReq, paymentMethodId string, idempotencyKey string) (*OrderResp, error) {
+	if orderReq.CustomerID == "" {
+		return nil, errors.New("merchant: charge needs the customer ID")
+	}
+
+	methods, err := c.customers.PaymentMethods(orderReq.CustomerID, true)
+	if err != nil {
+		return nil, err
+	}
+
+	var method *CustomerPaymentMethod
+	for _, m := range methods {
+		if m.SavedFor == SavedFor_MERCHANT && (paymentMethodId == "" || m.Id == paymentMethodId) {
+			method = m
+			break
+		}
+	}
+	if method == nil {
+		if paymentMethodId != "" {
+			return nil, fmt.Errorf("%w: %s", ErrNoPaymentMethod, paymentMethodId)
+		}
+		return nil, ErrNoPaymentMethod
+	}
+
+	order, err := c.orders.Create(orderReq, idempotencyKey)
+	if err != nil {
+		return nil, err
+	}
+
+	// a replayed create returns the order which may be paid already
+	if order.State.CanConfirm() {
+		order, err = c.orders.ConfirmWithPaymentMethod(order.Id, &ConfirmReq{
+			PaymentMethodId: method.Id,
+			Initiator:       PaymentInitiator_MERCHANT,
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if c.Timeout > 0 {
+		deadline := time.Now().Add(c.Timeout)
+		pollInterval := c.PollInterval
+		if pollInterval <= 0 {
+			pollInterval = 2 * time.Second
+		}
+		for {
+			payment := order.LastPayment()
+			if payment != nil && payment.State.Final() {
+				break
+			}
+			if time.Now().Add(pollInterval).After(deadline) {
+				return order, fmt.Errorf("merchant: payment of order %s is still pending", order.Id)
+			}
+			time.Sleep(pollInterval)
+
+			if order, err = c.orders.WithId(order.Id); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if payment := order.LastPayment(); payment != nil {
+		if err := payment.Err(order.Id); err != nil {
+			return order, err
+		}
+	}
+
+	return order, nil
+}
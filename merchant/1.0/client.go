@@ -1,5 +1,9 @@
 package merchant
 
+import (
+	"strings"
+)
+
 type Client struct {
 	apiKey string
 	domain string
@@ -12,7 +16,7 @@ type Client struct {
 func newClient(apiKey string, domain string) *Client {
 	return &Client{
 		apiKey: apiKey,
-		domain: apiKey,
+		domain: domain,
 		orderService: &OrderService{
 			apiKey: apiKey,
 			domain: domain,
@@ -36,14 +40,19 @@ func NewSandboxClient(apiKey string) *Client {
 	return newClient(apiKey, "https://sandbox-merchant.revolut.com")
 }
 
-func (m *Client) Order() *OrderService {
+// NewClientWithDomain creates a client of the API served on domain, e.g. the url of a merchanttest server.
+func NewClientWithDomain(apiKey string, domain string) *Client {
+	return newClient(apiKey, strings.TrimSuffix(domain, "/"))
+}
+
+func (m *Client) Order() OrderAPI {
 	return m.orderService
 }
 
-func (m *Client) Webhook() *WebhookService {
+func (m *Client) Webhook() WebhookAPI {
 	return m.webhookService
 }
 
-func (m *Client) Customer() *CustomerService {
+func (m *Client) Customer() CustomerAPI {
 	return m.customerService
 }
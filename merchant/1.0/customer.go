@@ -5,6 +5,8 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"net/url"
+	"time"
 
 	"github.com/adless-tech/go-revolut/merchant/1.0/request"
 )
@@ -21,14 +23,89 @@ type CreateCustomerReq struct {
 	Phone        string `json:"phone,omitempty"`
 }
 
-type CreateCustomerResp struct {
-	ID    string `json:"id,omitempty"`
+// UpdateCustomerReq changes only the fields which are set.
+type UpdateCustomerReq struct {
+	FullName     string `json:"full_name,omitempty"`
+	BusinessName string `json:"business_name,omitempty"`
+	Email        string `json:"email,omitempty"`
+	Phone        string `json:"phone,omitempty"`
+}
+
+// CreateCustomerResp is the customer returned by Create.
+//
+// Deprecated: use CustomerResp, its ID field is Id.
+type CreateCustomerResp = CustomerResp
+
+type CustomerResp struct {
+	// Customer ID, use it as OrderReq.CustomerID
+	Id string `json:"id,omitempty"`
+	// Customer full name
+	FullName string `json:"full_name,omitempty"`
+	// Customer business name
+	BusinessName string `json:"business_name,omitempty"`
+	// Customer e-mail
 	Email string `json:"email,omitempty"`
+	// Customer phone
+	Phone string `json:"phone,omitempty"`
+	// Customer creation date
+	CreatedAt time.Time `json:"created_at,omitempty"`
+	// Last update date
+	UpdatedAt time.Time `json:"updated_at,omitempty"`
+	// Saved payment methods, returned only by WithId
+	PaymentMethods []*CustomerPaymentMethod `json:"payment_methods,omitempty"`
+}
+
+type SavedFor string
+
+const (
+	// the customer can pay with the payment method in the checkout
+	SavedFor_CUSTOMER SavedFor = "CUSTOMER"
+	// the merchant can charge the payment method without the customer
+	SavedFor_MERCHANT SavedFor = "MERCHANT"
+)
+
+type PaymentMethodType string
+
+const (
+	PaymentMethodType_CARD        PaymentMethodType = "CARD"
+	PaymentMethodType_REVOLUT_PAY PaymentMethodType = "REVOLUT_PAY"
+)
+
+type CustomerPaymentMethod struct {
+	// Payment method ID
+	Id string `json:"id,omitempty"`
+	// Payment method type
+	Type PaymentMethodType `json:"type,omitempty"`
+	// Who can initiate a payment with the payment method
+	SavedFor      SavedFor             `json:"saved_for,omitempty"`
+	MethodDetails PaymentMethodDetails `json:"method_details,omitempty"`
+}
+
+type PaymentMethodDetails struct {
+	// Card BIN
+	Bin string `json:"bin,omitempty"`
+	// Card last four digits
+	Last4 string `json:"last4,omitempty"`
+	// Card expiry month
+	ExpiryMonth int `json:"expiry_month,omitempty"`
+	// Card expiry year
+	ExpiryYear int `json:"expiry_year,omitempty"`
+	// Cardholder name
+	CardholderName string `json:"cardholder_name,omitempty"`
+	// Card brand
+	Brand CardType `json:"brand,omitempty"`
+	// Card funding
+	Funding Funding `json:"funding,omitempty"`
+	// Card issuer country
+	IssuerCountry  string         `json:"issuer_country,omitempty"`
+	BillingAddress BillingAddress `json:"billing_address,omitempty"`
+	// Payment method creation date
+	CreatedAt time.Time `json:"created_at,omitempty"`
 }
 
 // Create creates a customer
 // https://developer.revolut.com/docs/api-reference/merchant/#tag/Customers/operation/createCustomer
-func (a *CustomerService) Create(req *CreateCustomerReq) (*CreateCustomerResp, error) {
+func (a *CustomerService) Create(req *CreateCustomerReq) (*CustomerResp, error) {
 	resp, statusCode, err := request.New(request.Config{
 		Method:      http.MethodPost,
 		Url:         fmt.Sprintf("%s/api/1.0/customers", a.domain),
@@ -40,14 +117,273 @@ func (a *CustomerService) Create(req *CreateCustomerReq) (*CreateCustomerResp, e
 		return nil, err
 	}
 
+	if statusCode != http.StatusOK && statusCode != http.StatusCreated {
+		return nil, errors.New(fmt.Sprintf("%s [status: %d]", string(resp), statusCode))
+	}
+
+	r := &CustomerResp{}
+	if err := json.Unmarshal(resp, r); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// List returns a page of the customers, page is counted from 1 and limit is 1000 max
+// https://developer.revolut.com/docs/api-reference/merchant/#tag/Customers/operation/retrieveAllCustomers
+func (a *CustomerService) List(limit, page int) ([]*CustomerResp, error) {
+	params := url.Values{}
+	if limit != 0 {
+		params.Add("limit", fmt.Sprintf("%d", limit))
+	}
+	if page != 0 {
+		params.Add("page", fmt.Sprintf("%d", page))
+	}
+
+	resp, statusCode, err := request.New(request.Config{
+		Method: http.MethodGet,
+		Url:    fmt.Sprintf("%s/api/1.0/customers?%s", a.domain, params.Encode()),
+		ApiKey: a.apiKey,
+	})
+	if err != nil {
+		return nil, err
+	}
+
 	if statusCode != http.StatusOK {
-		return nil, errors.New(string(resp))
+		return nil, errors.New(fmt.Sprintf("%s [status: %d]", string(resp), statusCode))
 	}
 
-	var r *CreateCustomerResp
+	r := []*CustomerResp{}
+	if err := json.Unmarshal(resp, &r); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// Iterate returns an iterator over all the customers fetching pages of limit customers as needed.
+func (a *CustomerService) Iterate(limit int) *CustomerIterator {
+	return NewCustomerIterator(a, limit)
+}
+
+// NewCustomerIterator returns an iterator over the customers listed by customers, pages of limit customers.
+func NewCustomerIterator(customers CustomerAPI, limit int) *CustomerIterator {
+	if limit == 0 {
+		limit = 100
+	}
+
+	return &CustomerIterator{
+		service: customers,
+		limit:   limit,
+	}
+}
+
+// CustomerIterator walks the pages of the customer list.
+type CustomerIterator struct {
+	service CustomerAPI
+	limit   int
+
+	page     []*CustomerResp
+	customer *CustomerResp
+	pageNo   int
+	last     bool
+	err      error
+}
+
+// Next advances to the next customer, it returns false at the end or on an error.
+func (it *CustomerIterator) Next() bool {
+	for len(it.page) == 0 {
+		if it.last || it.err != nil {
+			return false
+		}
+
+		it.pageNo++
+		it.page, it.err = it.service.List(it.limit, it.pageNo)
+		if len(it.page) < it.limit {
+			it.last = true
+		}
+	}
+
+	it.customer, it.page = it.page[0], it.page[1:]
+	return true
+}
+
+// Customer returns the current customer.
+func (it *CustomerIterator) Customer() *CustomerResp {
+	return it.customer
+}
+
+// Err returns the error which stopped the iteration.
+func (it *CustomerIterator) Err() error {
+	return it.err
+}
+
+// WithId retrieves a customer with the saved payment methods
+// https://developer.revolut.com/docs/api-reference/merchant/#tag/Customers/operation/retrieveCustomer
+func (a *CustomerService) WithId(id string) (*CustomerResp, error) {
+	resp, statusCode, err := request.New(request.Config{
+		Method: http.MethodGet,
+		Url:    fmt.Sprintf("%s/api/1.0/customers/%s", a.domain, id),
+		ApiKey: a.apiKey,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if statusCode != http.StatusOK {
+		return nil, errors.New(fmt.Sprintf("%s [status: %d]", string(resp), statusCode))
+	}
+
+	r := &CustomerResp{}
 	if err := json.Unmarshal(resp, r); err != nil {
 		return nil, err
 	}
 
 	return r, nil
 }
+
+// Update updates the fields of a customer which are set
+// https://developer.revolut.com/docs/api-reference/merchant/#tag/Customers/operation/updateCustomer
+func (a *CustomerService) Update(id string, req *UpdateCustomerReq) (*CustomerResp, error) {
+	resp, statusCode, err := request.New(request.Config{
+		Method:      http.MethodPatch,
+		Url:         fmt.Sprintf("%s/api/1.0/customers/%s", a.domain, id),
+		ApiKey:      a.apiKey,
+		Body:        req,
+		ContentType: request.ContentType_APPLICATION_JSON,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if statusCode != http.StatusOK {
+		return nil, errors.New(fmt.Sprintf("%s [status: %d]", string(resp), statusCode))
+	}
+
+	r := &CustomerResp{}
+	if err := json.Unmarshal(resp, r); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// Delete deletes a customer with the saved payment methods
+// https://developer.revolut.com/docs/api-reference/merchant/#tag/Customers/operation/deleteCustomer
+func (a *CustomerService) Delete(id string) error {
+	resp, statusCode, err := request.New(request.Config{
+		Method: http.MethodDelete,
+		Url:    fmt.Sprintf("%s/api/1.0/customers/%s", a.domain, id),
+		ApiKey: a.apiKey,
+	})
+	if err != nil {
+		return err
+	}
+
+	if statusCode != http.StatusNoContent && statusCode != http.StatusOK {
+		return errors.New(fmt.Sprintf("%s [status: %d]", string(resp), statusCode))
+	}
+
+	return nil
+}
+
+// PaymentMethods lists the saved payment methods of a customer,
+// with onlyMerchant only the ones the merchant can charge
+// https://developer.revolut.com/docs/api-reference/merchant/#tag/Customers/operation/retrieveAllPaymentMethods
+func (a *CustomerService) PaymentMethods(customerId string, onlyMerchant bool) ([]*CustomerPaymentMethod, error) {
+	params := url.Values{}
+	if onlyMerchant {
+		params.Add("only_merchant", "true")
+	}
+
+	resp, statusCode, err := request.New(request.Config{
+		Method: http.MethodGet,
+		Url:    fmt.Sprintf("%s/api/1.0/customers/%s/payment-methods?%s", a.domain, customerId, params.Encode()),
+		ApiKey: a.apiKey,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if statusCode != http.StatusOK {
+		return nil, errors.New(fmt.Sprintf("%s [status: %d]", string(resp), statusCode))
+	}
+
+	r := []*CustomerPaymentMethod{}
+	if err := json.Unmarshal(resp, &r); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// PaymentMethod retrieves a saved payment method of a customer
+// https://developer.revolut.com/docs/api-reference/merchant/#tag/Customers/operation/retrievePaymentMethod
+func (a *CustomerService) PaymentMethod(customerId, paymentMethodId string) (*CustomerPaymentMethod, error) {
+	resp, statusCode, err := request.New(request.Config{
+		Method: http.MethodGet,
+		Url:    fmt.Sprintf("%s/api/1.0/customers/%s/payment-methods/%s", a.domain, customerId, paymentMethodId),
+		ApiKey: a.apiKey,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if statusCode != http.StatusOK {
+		return nil, errors.New(fmt.Sprintf("%s [status: %d]", string(resp), statusCode))
+	}
+
+	r := &CustomerPaymentMethod{}
+	if err := json.Unmarshal(resp, r); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// UpdatePaymentMethod changes who can initiate payments with a saved payment method
+// https://developer.revolut.com/docs/api-reference/merchant/#tag/Customers/operation/updatePaymentMethod
+func (a *CustomerService) UpdatePaymentMethod(customerId, paymentMethodId string, savedFor SavedFor) (*CustomerPaymentMethod, error) {
+	resp, statusCode, err := request.New(request.Config{
+		Method: http.MethodPatch,
+		Url:    fmt.Sprintf("%s/api/1.0/customers/%s/payment-methods/%s", a.domain, customerId, paymentMethodId),
+		ApiKey: a.apiKey,
+		Body: struct {
+			SavedFor SavedFor `json:"saved_for"`
+		}{SavedFor: savedFor},
+		ContentType: request.ContentType_APPLICATION_JSON,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if statusCode != http.StatusOK {
+		return nil, errors.New(fmt.Sprintf("%s [status: %d]", string(resp), statusCode))
+	}
+
+	r := &CustomerPaymentMethod{}
+	if err := json.Unmarshal(resp, r); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// DeletePaymentMethod deletes a saved payment method of a customer
+// https://developer.revolut.com/docs/api-reference/merchant/#tag/Customers/operation/deletePaymentMethod
+func (a *CustomerService) DeletePaymentMethod(customerId, paymentMethodId string) error {
+	resp, statusCode, err := request.New(request.Config{
+		Method: http.MethodDelete,
+		Url:    fmt.Sprintf("%s/api/1.0/customers/%s/payment-methods/%s", a.domain, customerId, paymentMethodId),
+		ApiKey: a.apiKey,
+	})
+	if err != nil {
+		return err
+	}
+
+	if statusCode != http.StatusNoContent && statusCode != http.StatusOK {
+		return errors.New(fmt.Sprintf("%s [status: %d]", string(resp), statusCode))
+	}
+
+	return nil
+}
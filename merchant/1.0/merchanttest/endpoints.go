@@ -0,0 +1,648 @@
+package merchanttest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	merchant "github.com/adless-tech/go-revolut/merchant/1.0"
+)
+
+// ordersEndpoint serves /api/1.0/orders, it returns the events to deliver.
+func (s *Server) ordersEndpoint(w http.ResponseWriter, r *http.Request, path []string, body []byte) []*merchant.WebhookResp {
+	switch {
+	case len(path) == 0 && r.Method == http.MethodPost:
+		s.createOrder(w, body)
+		return nil
+	case len(path) == 0 && r.Method == http.MethodGet:
+		s.listOrders(w, r)
+		return nil
+	}
+
+	order := s.order(path[0])
+	if order == nil {
+		writeError(w, http.StatusNotFound, "not_found", fmt.Sprintf("Order %s not found", path[0]))
+		return nil
+	}
+
+	switch {
+	case len(path) == 1 && r.Method == http.MethodGet:
+		writeJson(w, http.StatusOK, order)
+	case len(path) == 1 && r.Method == http.MethodPatch:
+		s.updateOrder(w, order, body)
+	case len(path) == 2 && r.Method == http.MethodPost && path[1] == "confirm":
+		return s.confirmOrder(w, order, body)
+	case len(path) == 2 && r.Method == http.MethodPost && path[1] == "capture":
+		return s.captureOrder(w, order, body)
+	case len(path) == 2 && r.Method == http.MethodPost && path[1] == "cancel":
+		return s.cancelOrder(w, order)
+	case len(path) == 2 && r.Method == http.MethodPost && path[1] == "refund":
+		s.refundOrder(w, order, body)
+	default:
+		writeError(w, http.StatusNotFound, "not_found", "Not found")
+	}
+
+	return nil
+}
+
+func (s *Server) createOrder(w http.ResponseWriter, body []byte) {
+	req := &merchant.OrderReq{}
+	if err := json.Unmarshal(body, req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_request", err.Error())
+		return
+	}
+	if req.Amount <= 0 || len(req.Currency) != 3 {
+		writeError(w, http.StatusUnprocessableEntity, "invalid_request", "amount and currency are required")
+		return
+	}
+
+	email := req.CustomerEmail
+	if req.CustomerID != "" {
+		customer := s.customer(req.CustomerID)
+		if customer == nil {
+			writeError(w, http.StatusUnprocessableEntity, "invalid_request", fmt.Sprintf("Customer %s not found", req.CustomerID))
+			return
+		}
+		if email == "" {
+			email = customer.Email
+		}
+	}
+
+	captureMode := req.CaptureMode
+	if captureMode == "" {
+		captureMode = merchant.CaptureMode_AUTOMATIC
+	}
+
+	now := s.now()
+	publicId := newId()
+	order := &merchant.OrderResp{
+		Id:                     newId(),
+		PublicId:               publicId,
+		Type:                   merchant.OrderType_PAYMENT,
+		State:                  merchant.OrderState_PENDING,
+		CreatedDate:            now,
+		UpdatedDate:            now,
+		OrderAmount:            merchant.Amount{Value: req.Amount, Currency: req.Currency},
+		MerchantOrderExtRef:    req.MerchantOrderID,
+		MerchantCustomerExtRef: req.MerchantCustomerID,
+		Email:                  email,
+		CustomerID:             req.CustomerID,
+		Description:            req.Description,
+		CaptureMode:            captureMode,
+		SettlementCurrency:     req.SettlementCurrency,
+		CheckoutUrl:            s.URL + "/checkout/" + publicId,
+		RedirectUrl:            req.RedirectUrl,
+		CancelAuthorisedAfter:  req.CancelAuthorisedAfter,
+		LineItems:              req.LineItems,
+		Metadata:               req.Metadata,
+		IndustryData:           req.IndustryData,
+	}
+	if req.ShippingAddress != nil {
+		order.ShippingAddress = *req.ShippingAddress
+	}
+	s.orders = append(s.orders, order)
+
+	writeJson(w, http.StatusCreated, order)
+}
+
+func (s *Server) listOrders(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+
+	limit := 100
+	if q.Get("limit") != "" {
+		var err error
+		if limit, err = strconv.Atoi(q.Get("limit")); err != nil || limit < 1 || limit > 1000 {
+			writeError(w, http.StatusBadRequest, "invalid_request", "invalid limit")
+			return
+		}
+	}
+
+	var dates [3]int64
+	for i, name := range []string{"created_before", "from_created_date", "to_created_date"} {
+		if q.Get(name) == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339Nano, q.Get(name))
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "invalid_request", fmt.Sprintf("invalid %s", name))
+			return
+		}
+		dates[i] = t.UnixNano() / int64(time.Millisecond)
+	}
+
+	states := map[merchant.OrderState]bool{}
+	for _, state := range q["state"] {
+		states[merchant.OrderState(state)] = true
+	}
+
+	list := []*merchant.OrderResp{}
+	for _, order := range s.orders {
+		switch {
+		case dates[0] != 0 && order.CreatedDate >= dates[0],
+			dates[1] != 0 && order.CreatedDate < dates[1],
+			dates[2] != 0 && order.CreatedDate > dates[2],
+			q.Get("customer_id") != "" && order.CustomerID != q.Get("customer_id"),
+			q.Get("email") != "" && order.Email != q.Get("email"),
+			q.Get("merchant_order_ext_ref") != "" && order.MerchantOrderExtRef != q.Get("merchant_order_ext_ref"),
+			len(states) > 0 && !states[order.State]:
+			continue
+		}
+		list = append(list, order)
+	}
+
+	sort.SliceStable(list, func(i, j int) bool {
+		return list[i].CreatedDate > list[j].CreatedDate
+	})
+	if len(list) > limit {
+		list = list[:limit]
+	}
+
+	writeJson(w, http.StatusOK, list)
+}
+
+func (s *Server) updateOrder(w http.ResponseWriter, order *merchant.OrderResp, body []byte) {
+	req := &merchant.OrderUpdateReq{}
+	if err := json.Unmarshal(body, req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_request", err.Error())
+		return
+	}
+	if order.State != merchant.OrderState_PENDING {
+		writeError(w, http.StatusUnprocessableEntity, "invalid_order_state", fmt.Sprintf("Order is %s", order.State))
+		return
+	}
+
+	if req.Amount != 0 {
+		order.OrderAmount.Value = req.Amount
+	}
+	if req.Currency != "" {
+		order.OrderAmount.Currency = req.Currency
+	}
+	if req.SettlementCurrency != "" {
+		order.SettlementCurrency = req.SettlementCurrency
+	}
+	if req.Description != "" {
+		order.Description = req.Description
+	}
+	if req.CaptureMode != "" {
+		order.CaptureMode = req.CaptureMode
+	}
+	if req.MerchantOrderExtRef != "" {
+		order.MerchantOrderExtRef = req.MerchantOrderExtRef
+	}
+	if req.MerchantCustomerExtRef != "" {
+		order.MerchantCustomerExtRef = req.MerchantCustomerExtRef
+	}
+	if req.CustomerEmail != "" {
+		order.Email = req.CustomerEmail
+	}
+	if req.ShippingAddress != nil {
+		order.ShippingAddress = *req.ShippingAddress
+	}
+	if req.LineItems != nil {
+		order.LineItems = req.LineItems
+	}
+	if req.Metadata != nil {
+		order.Metadata = req.Metadata
+	}
+	if req.RedirectUrl != "" {
+		order.RedirectUrl = req.RedirectUrl
+	}
+	if req.CancelAuthorisedAfter != "" {
+		order.CancelAuthorisedAfter = req.CancelAuthorisedAfter
+	}
+	if req.IndustryData != nil {
+		order.IndustryData = req.IndustryData
+	}
+	order.UpdatedDate = s.now()
+
+	writeJson(w, http.StatusOK, order)
+}
+
+func (s *Server) confirmOrder(w http.ResponseWriter, order *merchant.OrderResp, body []byte) []*merchant.WebhookResp {
+	req := &merchant.ConfirmReq{}
+	if len(body) > 0 {
+		if err := json.Unmarshal(body, req); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid_request", err.Error())
+			return nil
+		}
+	}
+	if !order.State.CanConfirm() {
+		writeError(w, http.StatusUnprocessableEntity, "invalid_order_state", fmt.Sprintf("Order is %s", order.State))
+		return nil
+	}
+
+	var method *merchant.CustomerPaymentMethod
+	if req.PaymentMethodId != "" {
+		for _, m := range s.paymentMethods[order.CustomerID] {
+			if m.Id == req.PaymentMethodId {
+				method = m
+			}
+		}
+		if method == nil {
+			writeError(w, http.StatusUnprocessableEntity, "invalid_request", fmt.Sprintf("Payment method %s not found for the order customer", req.PaymentMethodId))
+			return nil
+		}
+		if req.Initiator == merchant.PaymentInitiator_MERCHANT && method.SavedFor != merchant.SavedFor_MERCHANT {
+			writeError(w, http.StatusUnprocessableEntity, "invalid_request", "Payment method is not saved for the merchant")
+			return nil
+		}
+	}
+
+	events := s.authorise(order, method)
+	writeJson(w, http.StatusOK, order)
+
+	return events
+}
+
+func (s *Server) captureOrder(w http.ResponseWriter, order *merchant.OrderResp, body []byte) []*merchant.WebhookResp {
+	req := struct {
+		Amount int `json:"amount"`
+	}{}
+	if len(body) > 0 {
+		if err := json.Unmarshal(body, &req); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid_request", err.Error())
+			return nil
+		}
+	}
+	if order.State != merchant.OrderState_AUTHORISED {
+		writeError(w, http.StatusUnprocessableEntity, "invalid_order_state", fmt.Sprintf("Order is %s", order.State))
+		return nil
+	}
+
+	capturable := order.CapturableAmount()
+	amount := req.Amount
+	if amount == 0 {
+		amount = capturable
+	}
+	if amount < 0 || amount > capturable {
+		writeError(w, http.StatusUnprocessableEntity, "invalid_amount", fmt.Sprintf("Amount %d exceeds the capturable amount %d", amount, capturable))
+		return nil
+	}
+
+	now := s.now()
+	order.UpdatedDate = now
+	if order.CapturedAmount() == 0 && amount == order.OrderAmount.Value {
+		for i := range order.Payments {
+			if order.Payments[i].State == merchant.PaymentState_AUTHORISED {
+				order.Payments[i].State = merchant.PaymentState_COMPLETED
+				order.Payments[i].UpdatedDate = now
+			}
+		}
+	} else {
+		order.Payments = append(order.Payments, merchant.Payment{
+			Type:        "CARD",
+			Amount:      merchant.Amount{Value: amount, Currency: order.OrderAmount.Currency},
+			State:       merchant.PaymentState_COMPLETED,
+			CreatedDate: now,
+			UpdatedDate: now,
+		})
+	}
+
+	if order.CapturedAmount() < order.OrderAmount.Value {
+		writeJson(w, http.StatusOK, order)
+		return nil
+	}
+
+	order.State = merchant.OrderState_COMPLETED
+	order.CompletedDate = now
+	writeJson(w, http.StatusOK, order)
+
+	return []*merchant.WebhookResp{s.event(merchant.WebhookEvent_ORDER_COMPLETED, order)}
+}
+
+func (s *Server) cancelOrder(w http.ResponseWriter, order *merchant.OrderResp) []*merchant.WebhookResp {
+	if !order.State.CanCancel() {
+		writeError(w, http.StatusUnprocessableEntity, "invalid_order_state", fmt.Sprintf("Order is %s", order.State))
+		return nil
+	}
+
+	now := s.now()
+	for i := range order.Payments {
+		if order.Payments[i].State == merchant.PaymentState_AUTHORISED {
+			order.Payments[i].State = merchant.PaymentState_CANCELLED
+			order.Payments[i].UpdatedDate = now
+		}
+	}
+	order.State = merchant.OrderState_CANCELLED
+	order.UpdatedDate = now
+	writeJson(w, http.StatusOK, order)
+
+	return []*merchant.WebhookResp{s.event(merchant.WebhookEvent_ORDER_CANCELLED, order)}
+}
+
+func (s *Server) refundOrder(w http.ResponseWriter, order *merchant.OrderResp, body []byte) {
+	req := &merchant.RefundReq{}
+	if err := json.Unmarshal(body, req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_request", err.Error())
+		return
+	}
+	if !order.State.CanRefund() {
+		writeError(w, http.StatusUnprocessableEntity, "invalid_order_state", fmt.Sprintf("Order is %s", order.State))
+		return
+	}
+	if req.Currency != "" && req.Currency != order.OrderAmount.Currency {
+		writeError(w, http.StatusUnprocessableEntity, "invalid_request", fmt.Sprintf("Currency must be %s", order.OrderAmount.Currency))
+		return
+	}
+	if refundable := order.RefundableAmount(); req.Amount <= 0 || req.Amount > refundable {
+		writeError(w, http.StatusUnprocessableEntity, "invalid_amount", fmt.Sprintf("Amount %d exceeds the refundable amount %d", req.Amount, refundable))
+		return
+	}
+
+	now := s.now()
+	amount := merchant.Amount{Value: req.Amount, Currency: order.OrderAmount.Currency}
+	refund := &merchant.OrderResp{
+		Id:                     newId(),
+		Type:                   merchant.OrderType_REFUND,
+		State:                  merchant.OrderState_COMPLETED,
+		CreatedDate:            now,
+		UpdatedDate:            now,
+		CompletedDate:          now,
+		OrderAmount:            amount,
+		MerchantOrderExtRef:    req.MerchantOrderID,
+		MerchantCustomerExtRef: order.MerchantCustomerExtRef,
+		Email:                  order.Email,
+		CustomerID:             order.CustomerID,
+		Description:            req.Description,
+		Related:                []merchant.AttemptRelated{{Id: order.Id, Type: order.Type, Amount: order.OrderAmount}},
+	}
+	s.orders = append(s.orders, refund)
+
+	order.Related = append(order.Related, merchant.AttemptRelated{Id: refund.Id, Type: merchant.OrderType_REFUND, Amount: amount})
+	order.RefundedAmount = merchant.Amount{Value: order.RefundedAmount.Value + req.Amount, Currency: order.OrderAmount.Currency}
+	order.UpdatedDate = now
+
+	writeJson(w, http.StatusOK, refund)
+}
+
+// customersEndpoint serves /api/1.0/customers.
+func (s *Server) customersEndpoint(w http.ResponseWriter, r *http.Request, path []string, body []byte) {
+	switch {
+	case len(path) == 0 && r.Method == http.MethodPost:
+		s.createCustomer(w, body)
+		return
+	case len(path) == 0 && r.Method == http.MethodGet:
+		s.listCustomers(w, r)
+		return
+	}
+
+	customer := s.customer(path[0])
+	if customer == nil {
+		writeError(w, http.StatusNotFound, "not_found", fmt.Sprintf("Customer %s not found", path[0]))
+		return
+	}
+
+	switch {
+	case len(path) == 1 && r.Method == http.MethodGet:
+		c := *customer
+		c.PaymentMethods = s.paymentMethods[customer.Id]
+		writeJson(w, http.StatusOK, &c)
+	case len(path) == 1 && r.Method == http.MethodPatch:
+		s.updateCustomer(w, customer, body)
+	case len(path) == 1 && r.Method == http.MethodDelete:
+		for i, c := range s.customers {
+			if c == customer {
+				s.customers = append(s.customers[:i], s.customers[i+1:]...)
+				break
+			}
+		}
+		delete(s.paymentMethods, customer.Id)
+		w.WriteHeader(http.StatusNoContent)
+	case len(path) >= 2 && path[1] == "payment-methods":
+		s.paymentMethodsEndpoint(w, r, customer, path[2:], body)
+	default:
+		writeError(w, http.StatusNotFound, "not_found", "Not found")
+	}
+}
+
+func (s *Server) createCustomer(w http.ResponseWriter, body []byte) {
+	req := &merchant.CreateCustomerReq{}
+	if err := json.Unmarshal(body, req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_request", err.Error())
+		return
+	}
+	if req.Email == "" {
+		writeError(w, http.StatusUnprocessableEntity, "invalid_request", "email is required")
+		return
+	}
+
+	now := time.Now().UTC()
+	customer := &merchant.CustomerResp{
+		Id:           newId(),
+		FullName:     req.FullName,
+		BusinessName: req.BusinessName,
+		Email:        req.Email,
+		Phone:        req.Phone,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	}
+	s.customers = append(s.customers, customer)
+
+	writeJson(w, http.StatusCreated, customer)
+}
+
+func (s *Server) listCustomers(w http.ResponseWriter, r *http.Request) {
+	limit, page := 100, 1
+	if v := r.URL.Query().Get("limit"); v != "" {
+		var err error
+		if limit, err = strconv.Atoi(v); err != nil || limit < 1 {
+			writeError(w, http.StatusBadRequest, "invalid_request", "invalid limit")
+			return
+		}
+	}
+	if v := r.URL.Query().Get("page"); v != "" {
+		var err error
+		if page, err = strconv.Atoi(v); err != nil || page < 1 {
+			writeError(w, http.StatusBadRequest, "invalid_request", "invalid page")
+			return
+		}
+	}
+
+	list := []*merchant.CustomerResp{}
+	for i := (page - 1) * limit; i < len(s.customers) && i < page*limit; i++ {
+		list = append(list, s.customers[i])
+	}
+
+	writeJson(w, http.StatusOK, list)
+}
+
+func (s *Server) updateCustomer(w http.ResponseWriter, customer *merchant.CustomerResp, body []byte) {
+	req := &merchant.UpdateCustomerReq{}
+	if err := json.Unmarshal(body, req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_request", err.Error())
+		return
+	}
+
+	if req.FullName != "" {
+		customer.FullName = req.FullName
+	}
+	if req.BusinessName != "" {
+		customer.BusinessName = req.BusinessName
+	}
+	if req.Email != "" {
+		customer.Email = req.Email
+	}
+	if req.Phone != "" {
+		customer.Phone = req.Phone
+	}
+	customer.UpdatedAt = time.Now().UTC()
+
+	writeJson(w, http.StatusOK, customer)
+}
+
+func (s *Server) paymentMethodsEndpoint(w http.ResponseWriter, r *http.Request, customer *merchant.CustomerResp, path []string, body []byte) {
+	methods := s.paymentMethods[customer.Id]
+
+	if len(path) == 0 && r.Method == http.MethodGet {
+		onlyMerchant := r.URL.Query().Get("only_merchant") == "true"
+
+		list := []*merchant.CustomerPaymentMethod{}
+		for _, method := range methods {
+			if !onlyMerchant || method.SavedFor == merchant.SavedFor_MERCHANT {
+				list = append(list, method)
+			}
+		}
+		writeJson(w, http.StatusOK, list)
+		return
+	}
+	if len(path) != 1 {
+		writeError(w, http.StatusNotFound, "not_found", "Not found")
+		return
+	}
+
+	index := -1
+	for i, method := range methods {
+		if method.Id == path[0] {
+			index = i
+		}
+	}
+	if index < 0 {
+		writeError(w, http.StatusNotFound, "not_found", fmt.Sprintf("Payment method %s not found", path[0]))
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		writeJson(w, http.StatusOK, methods[index])
+	case http.MethodPatch:
+		req := struct {
+			SavedFor merchant.SavedFor `json:"saved_for"`
+		}{}
+		if err := json.Unmarshal(body, &req); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid_request", err.Error())
+			return
+		}
+		if req.SavedFor != merchant.SavedFor_CUSTOMER && req.SavedFor != merchant.SavedFor_MERCHANT {
+			writeError(w, http.StatusUnprocessableEntity, "invalid_request", "invalid saved_for")
+			return
+		}
+		methods[index].SavedFor = req.SavedFor
+		writeJson(w, http.StatusOK, methods[index])
+	case http.MethodDelete:
+		s.paymentMethods[customer.Id] = append(methods[:index], methods[index+1:]...)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "not_allowed", "Method not allowed")
+	}
+}
+
+// orderEvents are the events a web-hook set without events is subscribed to.
+var orderEvents = []merchant.WebhookEvent{
+	merchant.WebhookEvent_ORDER_COMPLETED,
+	merchant.WebhookEvent_ORDER_AUTHORISED,
+	merchant.WebhookEvent_ORDER_CANCELLED,
+	merchant.WebhookEvent_ORDER_PAYMENT_AUTHENTICATED,
+	merchant.WebhookEvent_ORDER_PAYMENT_DECLINED,
+	merchant.WebhookEvent_ORDER_PAYMENT_FAILED,
+}
+
+// webhooksEndpoint serves /api/1.0/webhooks.
+func (s *Server) webhooksEndpoint(w http.ResponseWriter, r *http.Request, path []string, body []byte) {
+	switch {
+	case len(path) == 0 && r.Method == http.MethodPost:
+		req := &merchant.WebhookReq{}
+		if err := json.Unmarshal(body, req); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid_request", err.Error())
+			return
+		}
+
+		// Set replaces the web-hooks with one subscribed to every order event, an empty url revokes them
+		if len(req.Events) == 0 {
+			s.webhooks = nil
+			if req.Url != "" {
+				s.webhooks = append(s.webhooks, s.newWebhook(req.Url, orderEvents))
+			}
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		if !strings.HasPrefix(req.Url, "http") {
+			writeError(w, http.StatusUnprocessableEntity, "invalid_request", "invalid url")
+			return
+		}
+
+		hook := s.newWebhook(req.Url, req.Events)
+		s.webhooks = append(s.webhooks, hook)
+		writeJson(w, http.StatusCreated, hook)
+		return
+	case len(path) == 0 && r.Method == http.MethodGet:
+		list := []*merchant.Webhook{}
+		for _, hook := range s.webhooks {
+			h := *hook
+			h.SigningSecret = ""
+			list = append(list, &h)
+		}
+		writeJson(w, http.StatusOK, list)
+		return
+	}
+
+	index := -1
+	for i, hook := range s.webhooks {
+		if hook.Id == path[0] {
+			index = i
+		}
+	}
+	if index < 0 {
+		writeError(w, http.StatusNotFound, "not_found", fmt.Sprintf("Webhook %s not found", path[0]))
+		return
+	}
+	hook := s.webhooks[index]
+
+	switch {
+	case len(path) == 1 && r.Method == http.MethodGet:
+		writeJson(w, http.StatusOK, hook)
+	case len(path) == 1 && r.Method == http.MethodPut:
+		req := &merchant.WebhookReq{}
+		if err := json.Unmarshal(body, req); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid_request", err.Error())
+			return
+		}
+		if req.Url != "" {
+			hook.Url = req.Url
+		}
+		if len(req.Events) > 0 {
+			hook.Events = req.Events
+		}
+		writeJson(w, http.StatusOK, hook)
+	case len(path) == 1 && r.Method == http.MethodDelete:
+		s.webhooks = append(s.webhooks[:index], s.webhooks[index+1:]...)
+		w.WriteHeader(http.StatusNoContent)
+	case len(path) == 2 && r.Method == http.MethodPost && path[1] == "rotate-signing-secret":
+		hook.SigningSecret = "wsk_" + randomHex(16)
+		writeJson(w, http.StatusOK, hook)
+	default:
+		writeError(w, http.StatusNotFound, "not_found", "Not found")
+	}
+}
+
+func (s *Server) newWebhook(url string, events []merchant.WebhookEvent) *merchant.Webhook {
+	return &merchant.Webhook{
+		Id:            newId(),
+		Url:           url,
+		Events:        events,
+		SigningSecret: "wsk_" + randomHex(16),
+	}
+}
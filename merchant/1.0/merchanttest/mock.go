@@ -0,0 +1,344 @@
+package merchanttest
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	merchant "github.com/adless-tech/go-revolut/merchant/1.0"
+)
+
+// ErrNotScripted is returned by a mock method without a scripted response.
+var ErrNotScripted = errors.New("merchanttest: no scripted response")
+
+// Call is a call recorded by a mock.
+type Call struct {
+	// the method name, e.g. Create
+	Method string
+	// the arguments of the call
+	Args []interface{}
+}
+
+type response struct {
+	value interface{}
+	err   error
+}
+
+// Mock records the calls of a mock service and returns the responses scripted by Return.
+// The zero value is ready to use.
+type Mock struct {
+	mu        sync.Mutex
+	calls     []Call
+	responses map[string][]response
+}
+
+// Return scripts the response of the next call of the method, value has the type of the
+// first result of the method, nil for the methods returning only an error.
+// The responses of a method queue up, the last one is returned for all the following calls.
+func (m *Mock) Return(method string, value interface{}, err error) *Mock {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.responses == nil {
+		m.responses = map[string][]response{}
+	}
+	m.responses[method] = append(m.responses[method], response{value: value, err: err})
+
+	return m
+}
+
+// Calls returns the recorded calls of the method, all the calls when method is empty.
+func (m *Mock) Calls(method string) []Call {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	calls := []Call{}
+	for _, call := range m.calls {
+		if method == "" || call.Method == method {
+			calls = append(calls, call)
+		}
+	}
+
+	return calls
+}
+
+// Reset forgets the recorded calls and the scripted responses.
+func (m *Mock) Reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.calls = nil
+	m.responses = nil
+}
+
+func (m *Mock) record(method string, args ...interface{}) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.calls = append(m.calls, Call{Method: method, Args: args})
+}
+
+// called records the call and returns its scripted response.
+func (m *Mock) called(method string, args ...interface{}) (interface{}, error) {
+	m.record(method, args...)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	queue := m.responses[method]
+	if len(queue) == 0 {
+		return nil, fmt.Errorf("%w for %s", ErrNotScripted, method)
+	}
+	r := queue[0]
+	if len(queue) > 1 {
+		m.responses[method] = queue[1:]
+	}
+
+	return r.value, r.err
+}
+
+// MockClient is a merchant.API of mock services.
+type MockClient struct {
+	Orders    *MockOrderService
+	Webhooks  *MockWebhookService
+	Customers *MockCustomerService
+}
+
+func NewMockClient() *MockClient {
+	return &MockClient{
+		Orders:    &MockOrderService{},
+		Webhooks:  &MockWebhookService{},
+		Customers: &MockCustomerService{},
+	}
+}
+
+func (c *MockClient) Order() merchant.OrderAPI {
+	return c.Orders
+}
+
+func (c *MockClient) Webhook() merchant.WebhookAPI {
+	return c.Webhooks
+}
+
+func (c *MockClient) Customer() merchant.CustomerAPI {
+	return c.Customers
+}
+
+// MockOrderService is a merchant.OrderAPI returning scripted responses.
+// Iterate pages through the responses scripted for List.
+type MockOrderService struct {
+	Mock
+}
+
+func (m *MockOrderService) Create(orderReq *merchant.OrderReq, idempotencyKey string) (*merchant.OrderResp, error) {
+	v, err := m.called("Create", orderReq, idempotencyKey)
+	return orderResp(v), err
+}
+
+func (m *MockOrderService) WithId(id string) (*merchant.OrderResp, error) {
+	v, err := m.called("WithId", id)
+	return orderResp(v), err
+}
+
+func (m *MockOrderService) List(orderListReq *merchant.OrderListReq) ([]*merchant.OrderResp, error) {
+	v, err := m.called("List", orderListReq)
+	if v == nil {
+		return nil, err
+	}
+	return v.([]*merchant.OrderResp), err
+}
+
+func (m *MockOrderService) Iterate(orderListReq *merchant.OrderListReq) *merchant.OrderIterator {
+	m.record("Iterate", orderListReq)
+	return merchant.NewOrderIterator(m, orderListReq)
+}
+
+func (m *MockOrderService) Update(id string, orderUpdateReq *merchant.OrderUpdateReq) (*merchant.OrderResp, error) {
+	v, err := m.called("Update", id, orderUpdateReq)
+	return orderResp(v), err
+}
+
+func (m *MockOrderService) Confirm(id string) (*merchant.OrderResp, error) {
+	v, err := m.called("Confirm", id)
+	return orderResp(v), err
+}
+
+func (m *MockOrderService) ConfirmWithPaymentMethod(id string, confirmReq *merchant.ConfirmReq) (*merchant.OrderResp, error) {
+	v, err := m.called("ConfirmWithPaymentMethod", id, confirmReq)
+	return orderResp(v), err
+}
+
+func (m *MockOrderService) Capture(id string) (*merchant.OrderResp, error) {
+	v, err := m.called("Capture", id)
+	return orderResp(v), err
+}
+
+func (m *MockOrderService) CaptureWithAmount(id string, amount int) (*merchant.OrderResp, error) {
+	v, err := m.called("CaptureWithAmount", id, amount)
+	return orderResp(v), err
+}
+
+func (m *MockOrderService) Cancel(id string) (*merchant.OrderResp, error) {
+	v, err := m.called("Cancel", id)
+	return orderResp(v), err
+}
+
+func (m *MockOrderService) Refund(id string, refundReq *merchant.RefundReq) (*merchant.RefundResp, error) {
+	v, err := m.called("Refund", id, refundReq)
+	return refundResp(v), err
+}
+
+func (m *MockOrderService) RefundWithIdempotencyKey(id string, refundReq *merchant.RefundReq, idempotencyKey string) (*merchant.RefundResp, error) {
+	v, err := m.called("RefundWithIdempotencyKey", id, refundReq, idempotencyKey)
+	return refundResp(v), err
+}
+
+// MockWebhookService is a merchant.WebhookAPI returning scripted responses.
+type MockWebhookService struct {
+	Mock
+}
+
+func (m *MockWebhookService) Set(webhookReq *merchant.WebhookUrl) error {
+	_, err := m.called("Set", webhookReq)
+	return err
+}
+
+func (m *MockWebhookService) Create(webhookReq *merchant.WebhookReq) (*merchant.Webhook, error) {
+	v, err := m.called("Create", webhookReq)
+	return webhookResp(v), err
+}
+
+func (m *MockWebhookService) List() ([]*merchant.Webhook, error) {
+	v, err := m.called("List")
+	if v == nil {
+		return nil, err
+	}
+	return v.([]*merchant.Webhook), err
+}
+
+func (m *MockWebhookService) WithId(id string) (*merchant.Webhook, error) {
+	v, err := m.called("WithId", id)
+	return webhookResp(v), err
+}
+
+func (m *MockWebhookService) Update(id string, webhookReq *merchant.WebhookReq) (*merchant.Webhook, error) {
+	v, err := m.called("Update", id, webhookReq)
+	return webhookResp(v), err
+}
+
+func (m *MockWebhookService) Delete(id string) error {
+	_, err := m.called("Delete", id)
+	return err
+}
+
+func (m *MockWebhookService) RotateSigningSecret(id string, expirationPeriod time.Duration) (*merchant.Webhook, error) {
+	v, err := m.called("RotateSigningSecret", id, expirationPeriod)
+	return webhookResp(v), err
+}
+
+// MockCustomerService is a merchant.CustomerAPI returning scripted responses.
+// Iterate pages through the responses scripted for List.
+type MockCustomerService struct {
+	Mock
+}
+
+func (m *MockCustomerService) Create(req *merchant.CreateCustomerReq) (*merchant.CustomerResp, error) {
+	v, err := m.called("Create", req)
+	return customerResp(v), err
+}
+
+func (m *MockCustomerService) List(limit, page int) ([]*merchant.CustomerResp, error) {
+	v, err := m.called("List", limit, page)
+	if v == nil {
+		return nil, err
+	}
+	return v.([]*merchant.CustomerResp), err
+}
+
+func (m *MockCustomerService) Iterate(limit int) *merchant.CustomerIterator {
+	m.record("Iterate", limit)
+	return merchant.NewCustomerIterator(m, limit)
+}
+
+func (m *MockCustomerService) WithId(id string) (*merchant.CustomerResp, error) {
+	v, err := m.called("WithId", id)
+	return customerResp(v), err
+}
+
+func (m *MockCustomerService) Update(id string, req *merchant.UpdateCustomerReq) (*merchant.CustomerResp, error) {
+	v, err := m.called("Update", id, req)
+	return customerResp(v), err
+}
+
+func (m *MockCustomerService) Delete(id string) error {
+	_, err := m.called("Delete", id)
+	return err
+}
+
+func (m *MockCustomerService) PaymentMethods(customerId string, onlyMerchant bool) ([]*merchant.CustomerPaymentMethod, error) {
+	v, err := m.called("PaymentMethods", customerId, onlyMerchant)
+	if v == nil {
+		return nil, err
+	}
+	return v.([]*merchant.CustomerPaymentMethod), err
+}
+
+func (m *MockCustomerService) PaymentMethod(customerId, paymentMethodId string) (*merchant.CustomerPaymentMethod, error) {
+	v, err := m.called("PaymentMethod", customerId, paymentMethodId)
+	return paymentMethod(v), err
+}
+
+func (m *MockCustomerService) UpdatePaymentMethod(customerId, paymentMethodId string, savedFor merchant.SavedFor) (*merchant.CustomerPaymentMethod, error) {
+	v, err := m.called("UpdatePaymentMethod", customerId, paymentMethodId, savedFor)
+	return paymentMethod(v), err
+}
+
+func (m *MockCustomerService) DeletePaymentMethod(customerId, paymentMethodId string) error {
+	_, err := m.called("DeletePaymentMethod", customerId, paymentMethodId)
+	return err
+}
+
+// the conversions of the scripted values panic on a value of a wrong type
+
+func orderResp(v interface{}) *merchant.OrderResp {
+	if v == nil {
+		return nil
+	}
+	return v.(*merchant.OrderResp)
+}
+
+func refundResp(v interface{}) *merchant.RefundResp {
+	if v == nil {
+		return nil
+	}
+	return v.(*merchant.RefundResp)
+}
+
+func webhookResp(v interface{}) *merchant.Webhook {
+	if v == nil {
+		return nil
+	}
+	return v.(*merchant.Webhook)
+}
+
+func customerResp(v interface{}) *merchant.CustomerResp {
+	if v == nil {
+		return nil
+	}
+	return v.(*merchant.CustomerResp)
+}
+
+func paymentMethod(v interface{}) *merchant.CustomerPaymentMethod {
+	if v == nil {
+		return nil
+	}
+	return v.(*merchant.CustomerPaymentMethod)
+}
+
+var (
+	_ merchant.API         = (*MockClient)(nil)
+	_ merchant.OrderAPI    = (*MockOrderService)(nil)
+	_ merchant.WebhookAPI  = (*MockWebhookService)(nil)
+	_ merchant.CustomerAPI = (*MockCustomerService)(nil)
+)
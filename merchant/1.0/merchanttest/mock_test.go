@@ -0,0 +1,28 @@
+package merchanttest_test
+
+import (
+	"errors"
+	"testing"
+
+	merchant "github.com/adless-tech/go-revolut/merchant/1.0"
+	"github.com/adless-tech/go-revolut/merchant/1.0/merchanttest"
+)
+
+func TestRefundGuardWithMock(t *testing.T) {
+	orders := &merchanttest.MockOrderService{}
+	orders.Return("WithId", &merchant.OrderResp{
+		Id:          "order-1",
+		State:       merchant.OrderState_AUTHORISED,
+		OrderAmount: merchant.Amount{Value: 1000, Currency: "GBP"},
+	}, nil)
+
+	_, err := merchant.NewRefundManager(orders).Refund("order-1", 0, "return-1", "")
+
+	var stateErr *merchant.OrderStateError
+	if !errors.As(err, &stateErr) {
+		t.Fatalf("error = %v, want an *OrderStateError", err)
+	}
+	if calls := orders.Calls("RefundWithIdempotencyKey"); len(calls) != 0 {
+		t.Errorf("refund endpoint called %d times, want 0", len(calls))
+	}
+}
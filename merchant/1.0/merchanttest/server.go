@@ -0,0 +1,482 @@
+// Package merchanttest provides an in-memory fake of the Revolut Merchant API for tests.
+package merchanttest
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+
+	merchant "github.com/adless-tech/go-revolut/merchant/1.0"
+	"github.com/adless-tech/go-revolut/webhook"
+)
+
+// Server is a stateful fake of the Merchant API served by an httptest.Server.
+// Orders are paid by Pay or by confirming them with a saved payment method,
+// automatic capture orders complete at once and manual ones wait for a capture.
+// The order events are posted to the registered web-hooks signed by their secrets
+// before the response of the request causing them is sent.
+type Server struct {
+	*httptest.Server
+
+	// the secret API key accepted by the server
+	ApiKey string
+
+	mu             sync.Mutex
+	lastDate       int64
+	orders         []*merchant.OrderResp
+	customers      []*merchant.CustomerResp
+	paymentMethods map[string][]*merchant.CustomerPaymentMethod
+	webhooks       []*merchant.Webhook
+	idempotency    map[string]*replay
+	failures       map[string]*replay
+	declines       []*decline
+}
+
+type replay struct {
+	fingerprint string
+	status      int
+	body        []byte
+	// closed when the request reserving the idempotency key is answered
+	done chan struct{}
+}
+
+type decline struct {
+	state  merchant.PaymentState
+	reason merchant.FailureReason
+}
+
+// NewServer starts a server without orders and customers, it is stopped by Close.
+func NewServer() *Server {
+	s := &Server{
+		ApiKey:         "sk_merchanttest",
+		paymentMethods: map[string][]*merchant.CustomerPaymentMethod{},
+		idempotency:    map[string]*replay{},
+		failures:       map[string]*replay{},
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+
+	return s
+}
+
+// NewClient returns a client of the server.
+func (s *Server) NewClient() *merchant.Client {
+	return merchant.NewClientWithDomain(s.ApiKey, s.URL)
+}
+
+// AddCustomer adds a customer.
+func (s *Server) AddCustomer(fullName, email string) *merchant.CustomerResp {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now().UTC()
+	customer := &merchant.CustomerResp{
+		Id:        newId(),
+		FullName:  fullName,
+		Email:     email,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	s.customers = append(s.customers, customer)
+
+	c := *customer
+	return &c
+}
+
+// AddPaymentMethod saves a test card of the customer.
+func (s *Server) AddPaymentMethod(customerId string, savedFor merchant.SavedFor) *merchant.CustomerPaymentMethod {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	method := &merchant.CustomerPaymentMethod{
+		Id:       newId(),
+		Type:     merchant.PaymentMethodType_CARD,
+		SavedFor: savedFor,
+		MethodDetails: merchant.PaymentMethodDetails{
+			Bin:            "459678",
+			Last4:          fmt.Sprintf("%04d", 1000+len(s.paymentMethods[customerId])),
+			ExpiryMonth:    12,
+			ExpiryYear:     time.Now().Year() + 3,
+			CardholderName: "Test Cardholder",
+			Brand:          merchant.CardType_VISA,
+			Funding:        merchant.Funding_DEBIT,
+			IssuerCountry:  "GB",
+			CreatedAt:      time.Now().UTC(),
+		},
+	}
+	s.paymentMethods[customerId] = append(s.paymentMethods[customerId], method)
+
+	c := *method
+	return &c
+}
+
+// Order returns a copy of the order or nil.
+func (s *Server) Order(id string) *merchant.OrderResp {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	order := s.order(id)
+	if order == nil {
+		return nil
+	}
+
+	return copyOrder(order)
+}
+
+// Pay pays the pending order as the customer would in the checkout.
+func (s *Server) Pay(orderId string) error {
+	s.mu.Lock()
+
+	order := s.order(orderId)
+	if order == nil {
+		s.mu.Unlock()
+		return fmt.Errorf("merchanttest: order %s not found", orderId)
+	}
+	if !order.State.CanConfirm() {
+		s.mu.Unlock()
+		return fmt.Errorf("merchanttest: order %s is %s", orderId, order.State)
+	}
+
+	events := s.authorise(order, nil)
+	s.mu.Unlock()
+
+	s.deliver(events)
+	return nil
+}
+
+// DeclineNext makes the next payment declined with the state, DECLINED, SOFT_DECLINED or FAILED,
+// and the reason. The calls queue up.
+func (s *Server) DeclineNext(state merchant.PaymentState, reason merchant.FailureReason) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.declines = append(s.declines, &decline{state: state, reason: reason})
+}
+
+// FailNext makes the next request of the method to the path, e.g. /api/1.0/orders,
+// fail with the status and the body.
+func (s *Server) FailNext(method, path string, status int, body string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.failures[method+" "+path] = &replay{status: status, body: []byte(body)}
+}
+
+// Fire posts the event of the order to the web-hooks subscribed to it.
+func (s *Server) Fire(event merchant.WebhookEvent, orderId string) error {
+	s.mu.Lock()
+	order := s.order(orderId)
+	if order == nil {
+		s.mu.Unlock()
+		return fmt.Errorf("merchanttest: order %s not found", orderId)
+	}
+	e := s.event(event, order)
+	s.mu.Unlock()
+
+	return s.deliver([]*merchant.WebhookResp{e})
+}
+
+// authorise pays the order with the saved payment method, or a test card when it is nil,
+// or declines it when a decline is queued.
+func (s *Server) authorise(order *merchant.OrderResp, method *merchant.CustomerPaymentMethod) []*merchant.WebhookResp {
+	now := s.now()
+	payment := merchant.Payment{
+		Type:        "CARD",
+		Amount:      order.OrderAmount,
+		CreatedDate: now,
+		UpdatedDate: now,
+		PaymentMethod: merchant.PaymentMethod{
+			Card: merchant.Card{
+				CardBrand:    merchant.CardType_VISA,
+				Funding:      merchant.Funding_DEBIT,
+				CardBin:      "459678",
+				CardLastFour: "0000",
+			},
+		},
+	}
+	if method != nil {
+		details := method.MethodDetails
+		payment.PaymentMethod.Card = merchant.Card{
+			CardBrand:      details.Brand,
+			Funding:        details.Funding,
+			CardBin:        details.Bin,
+			CardLastFour:   details.Last4,
+			CardExpiry:     fmt.Sprintf("%02d/%02d", details.ExpiryMonth, details.ExpiryYear%100),
+			CardholderName: details.CardholderName,
+		}
+	}
+	order.UpdatedDate = now
+
+	if len(s.declines) > 0 {
+		d := s.declines[0]
+		s.declines = s.declines[1:]
+
+		payment.State = d.state
+		payment.FailureReason = string(d.reason)
+		order.Payments = append(order.Payments, payment)
+
+		if d.state == merchant.PaymentState_FAILED {
+			order.State = merchant.OrderState_FAILED
+			return []*merchant.WebhookResp{s.event(merchant.WebhookEvent_ORDER_PAYMENT_FAILED, order)}
+		}
+		return []*merchant.WebhookResp{s.event(merchant.WebhookEvent_ORDER_PAYMENT_DECLINED, order)}
+	}
+
+	if order.CaptureMode == merchant.CaptureMode_MANUAL {
+		payment.State = merchant.PaymentState_AUTHORISED
+		order.Payments = append(order.Payments, payment)
+		order.State = merchant.OrderState_AUTHORISED
+		return []*merchant.WebhookResp{s.event(merchant.WebhookEvent_ORDER_AUTHORISED, order)}
+	}
+
+	payment.State = merchant.PaymentState_COMPLETED
+	order.Payments = append(order.Payments, payment)
+	order.State = merchant.OrderState_COMPLETED
+	order.CompletedDate = now
+	return []*merchant.WebhookResp{
+		s.event(merchant.WebhookEvent_ORDER_AUTHORISED, order),
+		s.event(merchant.WebhookEvent_ORDER_COMPLETED, order),
+	}
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	if f, ok := s.failures[r.Method+" "+r.URL.Path]; ok {
+		delete(s.failures, r.Method+" "+r.URL.Path)
+		s.mu.Unlock()
+		writeRaw(w, f.status, f.body)
+		return
+	}
+	s.mu.Unlock()
+
+	if r.Header.Get("Authorization") != "Bearer "+s.ApiKey {
+		writeError(w, http.StatusUnauthorized, "unauthenticated", "Invalid API key")
+		return
+	}
+
+	body := new(bytes.Buffer)
+	body.ReadFrom(r.Body)
+
+	key := r.Header.Get("x-idempotency-key")
+	fingerprint := r.Method + " " + r.URL.Path + " " + body.String()
+	var reserved *replay
+	for key != "" && reserved == nil {
+		s.mu.Lock()
+		prev, ok := s.idempotency[key]
+		if !ok {
+			// the key is reserved so a concurrent request with it waits for this one
+			reserved = &replay{fingerprint: fingerprint, done: make(chan struct{})}
+			s.idempotency[key] = reserved
+			s.mu.Unlock()
+			break
+		}
+		s.mu.Unlock()
+
+		if prev.fingerprint != fingerprint {
+			writeError(w, http.StatusUnprocessableEntity, "idempotency_key_reused", "The idempotency key was used with another request")
+			return
+		}
+		<-prev.done
+		if prev.status != 0 {
+			writeRaw(w, prev.status, prev.body)
+			return
+		}
+		// the request failed and released the key, this one is executed
+	}
+
+	rec := httptest.NewRecorder()
+	events := s.route(rec, r, body.Bytes())
+
+	if reserved != nil {
+		s.mu.Lock()
+		if rec.Code < http.StatusInternalServerError {
+			reserved.status = rec.Code
+			reserved.body = rec.Body.Bytes()
+		} else {
+			delete(s.idempotency, key)
+		}
+		s.mu.Unlock()
+		close(reserved.done)
+	}
+
+	for k, v := range rec.Header() {
+		w.Header()[k] = v
+	}
+	w.WriteHeader(rec.Code)
+	w.Write(rec.Body.Bytes())
+
+	s.deliver(events)
+}
+
+func (s *Server) route(w http.ResponseWriter, r *http.Request, body []byte) []*merchant.WebhookResp {
+	path := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if len(path) < 3 || path[0] != "api" || path[1] != "1.0" {
+		writeError(w, http.StatusNotFound, "not_found", "Not found")
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch path[2] {
+	case "orders":
+		return s.ordersEndpoint(w, r, path[3:], body)
+	case "customers":
+		s.customersEndpoint(w, r, path[3:], body)
+	case "webhooks":
+		s.webhooksEndpoint(w, r, path[3:], body)
+	default:
+		writeError(w, http.StatusNotFound, "not_found", "Not found")
+	}
+
+	return nil
+}
+
+// deliver posts the events to the subscribed web-hooks, it returns the first delivery error.
+func (s *Server) deliver(events []*merchant.WebhookResp) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	s.mu.Lock()
+	hooks := make([]merchant.Webhook, 0, len(s.webhooks))
+	for _, hook := range s.webhooks {
+		hooks = append(hooks, *hook)
+	}
+	s.mu.Unlock()
+
+	var firstErr error
+	for _, event := range events {
+		body, err := json.Marshal(event)
+		if err != nil {
+			return err
+		}
+
+		for _, hook := range hooks {
+			if !subscribed(hook.Events, event.Event) {
+				continue
+			}
+			if err := post(hook.Url, body, hook.SigningSecret); err != nil && firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+
+	return firstErr
+}
+
+func subscribed(events []merchant.WebhookEvent, event merchant.WebhookEvent) bool {
+	for _, e := range events {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+func post(url string, body []byte, secret string) error {
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if secret != "" {
+		webhook.SignRequest(req, secret, time.Now(), body)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("merchanttest: web-hook %s answered %d", url, resp.StatusCode)
+	}
+	return nil
+}
+
+func (s *Server) event(event merchant.WebhookEvent, order *merchant.OrderResp) *merchant.WebhookResp {
+	return &merchant.WebhookResp{
+		Event:               event,
+		OrderId:             order.Id,
+		MerchantOrderExtRef: order.MerchantOrderExtRef,
+	}
+}
+
+func (s *Server) order(id string) *merchant.OrderResp {
+	for _, order := range s.orders {
+		if order.Id == id {
+			return order
+		}
+	}
+	return nil
+}
+
+func (s *Server) customer(id string) *merchant.CustomerResp {
+	for _, customer := range s.customers {
+		if customer.Id == id {
+			return customer
+		}
+	}
+	return nil
+}
+
+// now returns the current time in ms, later than any returned before, so the orders are ordered by creation.
+func (s *Server) now() int64 {
+	now := time.Now().UnixNano() / int64(time.Millisecond)
+	if now <= s.lastDate {
+		now = s.lastDate + 1
+	}
+	s.lastDate = now
+
+	return now
+}
+
+func copyOrder(order *merchant.OrderResp) *merchant.OrderResp {
+	b, _ := json.Marshal(order)
+	c := &merchant.OrderResp{}
+	json.Unmarshal(b, c)
+	return c
+}
+
+func writeJson(w http.ResponseWriter, status int, v interface{}) {
+	b, _ := json.Marshal(v)
+	writeRaw(w, status, b)
+}
+
+func writeRaw(w http.ResponseWriter, status int, body []byte) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(body)
+}
+
+// writeError writes an error in the format of the Merchant API.
+func writeError(w http.ResponseWriter, status int, code, message string) {
+	writeJson(w, status, map[string]string{
+		"code":    code,
+		"message": message,
+	})
+}
+
+func newId() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+
+	h := hex.EncodeToString(b)
+	return h[:8] + "-" + h[8:12] + "-" + h[12:16] + "-" + h[16:20] + "-" + h[20:]
+}
+
+func randomHex(n int) string {
+	b := make([]byte, n)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
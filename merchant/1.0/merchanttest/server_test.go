@@ -0,0 +1,64 @@
+package merchanttest_test
+
+import (
+	"errors"
+	"testing"
+
+	merchant "github.com/adless-tech/go-revolut/merchant/1.0"
+	"github.com/adless-tech/go-revolut/merchant/1.0/merchanttest"
+)
+
+func TestCaptureAndRefundReplay(t *testing.T) {
+	srv := merchanttest.NewServer()
+	defer srv.Close()
+
+	mC := srv.NewClient()
+
+	req := &merchant.OrderReq{
+		Amount:      1000,
+		Currency:    "GBP",
+		CaptureMode: merchant.CaptureMode_MANUAL,
+	}
+	order, err := mC.Order().Create(req, "order-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	replayed, err := mC.Order().Create(req, "order-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if replayed.Id != order.Id {
+		t.Errorf("replayed order id = %s, want %s", replayed.Id, order.Id)
+	}
+
+	if err := srv.Pay(order.Id); err != nil {
+		t.Fatal(err)
+	}
+	order, err = merchant.CaptureAmount(mC.Order(), order.Id, 1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if order.State != merchant.OrderState_COMPLETED {
+		t.Fatalf("captured order state = %s, want %s", order.State, merchant.OrderState_COMPLETED)
+	}
+
+	refunds := merchant.NewRefundManager(mC.Order())
+	first, err := refunds.Refund(order.Id, 400, "return-1", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := refunds.Refund(order.Id, 400, "return-1", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if second.Id != first.Id {
+		t.Errorf("replayed refund id = %s, want %s", second.Id, first.Id)
+	}
+	if refunded := srv.Order(order.Id).RefundedAmount.Value; refunded != 400 {
+		t.Errorf("refunded amount = %d, want 400", refunded)
+	}
+
+	if _, err := refunds.Refund(order.Id, 700, "return-2", ""); !errors.Is(err, merchant.ErrOverRefund) {
+		t.Errorf("over refund error = %v, want %v", err, merchant.ErrOverRefund)
+	}
+}
@@ -77,6 +77,7 @@ const (
 	OrderState_PROCESSING OrderState = "PROCESSING"
 	OrderState_AUTHORISED OrderState = "AUTHORISED"
 	OrderState_COMPLETED  OrderState = "COMPLETED"
+	OrderState_CANCELLED  OrderState = "CANCELLED"
 	OrderState_FAILED     OrderState = "FAILED"
 )
 
@@ -102,19 +103,19 @@ type Fee struct {
 }
 
 type PaymentMethod struct {
-	Amount        Amount `json:"amount,omitempty"`
-	State         string `json:"state,omitempty"`
-	CreatedDate   int64  `json:"created_date,omitempty"`
-	UpdatedDate   int64  `json:"updated_date,omitempty"`
-	CompletedDate int    `json:"completed_date,omitempty"`
-	Card          Card   `json:"card,omitempty"`
-	FailureReason string `json:"failure_reason,omitempty"`
+	Amount        Amount       `json:"amount,omitempty"`
+	State         PaymentState `json:"state,omitempty"`
+	CreatedDate   int64        `json:"created_date,omitempty"`
+	UpdatedDate   int64        `json:"updated_date,omitempty"`
+	CompletedDate int          `json:"completed_date,omitempty"`
+	Card          Card         `json:"card,omitempty"`
+	FailureReason string       `json:"failure_reason,omitempty"`
 }
 
 type Payment struct {
 	Type          string        `json:"type,omitempty"`
 	Amount        Amount        `json:"amount,omitempty"`
-	State         string        `json:"state,omitempty"`
+	State         PaymentState  `json:"state,omitempty"`
 	CreatedDate   int64         `json:"created_date,omitempty"`
 	UpdatedDate   int64         `json:"updated_date,omitempty"`
 	CompletedDate int           `json:"completed_date,omitempty"`
@@ -215,6 +216,69 @@ type OrderResp struct {
 	ShippingAddress ShippingAddress  `json:"shipping_address,omitempty"`
 	Phone           string           `json:"phone,omitempty"`
 	CustomerID      string           `json:"customer_id,omitempty"`
+	// Order description
+	Description string `json:"description,omitempty"`
+	// Capture mode
+	CaptureMode CaptureMode `json:"capture_mode,omitempty"`
+	// Settlement currency
+	SettlementCurrency string `json:"settlement_currency,omitempty"`
+	// Link to the hosted checkout page of the order
+	CheckoutUrl string `json:"checkout_url,omitempty"`
+	// Url the customer is redirected to after the payment
+	RedirectUrl string `json:"redirect_url,omitempty"`
+	// Period after which an authorised order is cancelled, ISO 8601 duration
+	CancelAuthorisedAfter string     `json:"cancel_authorised_after,omitempty"`
+	LineItems             []LineItem `json:"line_items,omitempty"`
+	// Additional information about the order, up to 50 key-value pairs
+	Metadata map[string]string `json:"metadata,omitempty"`
+	// Industry specific information, e.g. airline or lodging data
+	IndustryData json.RawMessage `json:"industry_data,omitempty"`
+}
+
+type LineItemType string
+
+const (
+	LineItemType_PHYSICAL LineItemType = "physical"
+	LineItemType_SERVICE  LineItemType = "service"
+)
+
+type LineItem struct {
+	// Name of the item
+	Name string `json:"name,omitempty"`
+	// Type of the item
+	Type LineItemType `json:"type,omitempty"`
+	// Quantity of the item
+	Quantity LineItemQuantity `json:"quantity,omitempty"`
+	// Minor amount of one unit
+	UnitPriceAmount int `json:"unit_price_amount,omitempty"`
+	// Minor amount of the line including taxes and discounts
+	TotalAmount int `json:"total_amount,omitempty"`
+	// Merchant item ID
+	ExternalId string `json:"external_id,omitempty"`
+	// Discounts applied to the line
+	Discounts []LineItemAdjustment `json:"discounts,omitempty"`
+	// Taxes applied to the line
+	Taxes []LineItemAdjustment `json:"taxes,omitempty"`
+	// Links to the images of the item
+	ImageUrls []string `json:"image_urls,omitempty"`
+	// Item description
+	Description string `json:"description,omitempty"`
+	// Link to the item
+	Url string `json:"url,omitempty"`
+}
+
+type LineItemQuantity struct {
+	// Number of units
+	Value float64 `json:"value,omitempty"`
+	// Unit of measure, e.g. kg
+	Unit string `json:"unit,omitempty"`
+}
+
+type LineItemAdjustment struct {
+	// Name of the tax or discount
+	Name string `json:"name,omitempty"`
+	// Minor amount
+	Amount int `json:"amount,omitempty"`
 }
 
 type AttemptRelated struct {
@@ -264,6 +328,76 @@ type OrderReq struct {
 	MerchantCustomerID string `json:"merchant_customer_id,omitempty"`
 	// CustomerID is used to charge the card-on-file for the customer
 	CustomerID string `json:"customer_id,omitempty"`
+	// Shipping address
+	ShippingAddress *ShippingAddress `json:"shipping_address,omitempty"`
+	// Line items of the order
+	LineItems []LineItem `json:"line_items,omitempty"`
+	// Additional information about the order, up to 50 key-value pairs
+	Metadata map[string]string `json:"metadata,omitempty"`
+	// Url the customer is redirected to after the payment
+	RedirectUrl string `json:"redirect_url,omitempty"`
+	// Period after which an authorised order is cancelled, ISO 8601 duration, e.g. P3D
+	CancelAuthorisedAfter string `json:"cancel_authorised_after,omitempty"`
+	// Industry specific information, e.g. airline or lodging data
+	IndustryData json.RawMessage `json:"industry_data,omitempty"`
+}
+
+// OrderUpdateReq changes only the fields which are set.
+type OrderUpdateReq struct {
+	// Minor amount
+	Amount int `json:"amount,omitempty"`
+	// Currency code
+	Currency string `json:"currency,omitempty"`
+	// Settlement currency
+	SettlementCurrency string `json:"settlement_currency,omitempty"`
+	// Order description
+	Description string `json:"description,omitempty"`
+	// Capture mode
+	CaptureMode CaptureMode `json:"capture_mode,omitempty"`
+	// Merchant order ID
+	MerchantOrderExtRef string `json:"merchant_order_ext_ref,omitempty"`
+	// Merchant customer ID
+	MerchantCustomerExtRef string `json:"merchant_customer_ext_ref,omitempty"`
+	// Customer e-mail
+	CustomerEmail string `json:"customer_email,omitempty"`
+	// Shipping address
+	ShippingAddress *ShippingAddress `json:"shipping_address,omitempty"`
+	// Line items of the order, they replace the current ones
+	LineItems []LineItem `json:"line_items,omitempty"`
+	// Additional information about the order, it replaces the current one
+	Metadata map[string]string `json:"metadata,omitempty"`
+	// Url the customer is redirected to after the payment
+	RedirectUrl string `json:"redirect_url,omitempty"`
+	// Period after which an authorised order is cancelled, ISO 8601 duration
+	CancelAuthorisedAfter string `json:"cancel_authorised_after,omitempty"`
+	// Industry specific information
+	IndustryData json.RawMessage `json:"industry_data,omitempty"`
+}
+
+// UpdateReq returns an update request carrying all the updatable fields of the order,
+// so an order can be fetched, changed and updated without losing fields.
+func (o *OrderResp) UpdateReq() *OrderUpdateReq {
+	r := &OrderUpdateReq{
+		Amount:                 o.OrderAmount.Value,
+		Currency:               o.OrderAmount.Currency,
+		SettlementCurrency:     o.SettlementCurrency,
+		Description:            o.Description,
+		CaptureMode:            o.CaptureMode,
+		MerchantOrderExtRef:    o.MerchantOrderExtRef,
+		MerchantCustomerExtRef: o.MerchantCustomerExtRef,
+		CustomerEmail:          o.Email,
+		LineItems:              o.LineItems,
+		Metadata:               o.Metadata,
+		RedirectUrl:            o.RedirectUrl,
+		CancelAuthorisedAfter:  o.CancelAuthorisedAfter,
+		IndustryData:           o.IndustryData,
+	}
+	if o.ShippingAddress != (ShippingAddress{}) {
+		shippingAddress := o.ShippingAddress
+		r.ShippingAddress = &shippingAddress
+	}
+
+	return r
 }
 
 type RefundReq struct {
@@ -351,6 +485,7 @@ func (a *OrderService) WithId(id string) (*OrderResp, error) {
 
 // Capture: Once the payment is authorised, the merchant needs to
 // capture it in order for it to be sent into the processing stage.
+// The state of the order is not checked, CaptureOrder is the guarded entry point.
 // doc: https://revolut-engineering.github.io/api-docs/merchant-api/#backend-api-backend-api-order-object-capture-order
 func (a *OrderService) Capture(id string) (*OrderResp, error) {
 	resp, statusCode, err := request.New(request.Config{
@@ -376,6 +511,7 @@ func (a *OrderService) Capture(id string) (*OrderResp, error) {
 
 // Cancel: In case the payment has not been captured yet and the merchant decides
 // to not proceed with the order, the order can be cancelled manually.
+// The state of the order is not checked, CancelOrder is the guarded entry point.
 // doc: https://revolut-engineering.github.io/api-docs/merchant-api/#backend-api-backend-api-order-object-cancel-order
 func (a *OrderService) Cancel(id string) (*OrderResp, error) {
 	resp, statusCode, err := request.New(request.Config{
@@ -401,13 +537,47 @@ func (a *OrderService) Cancel(id string) (*OrderResp, error) {
 
 // Refund: In case the customer requires a refund for a payment that has been already captured,
 // the merchant can always issue a full or partial refund for a particular payment.
+// The state of the order is not checked, RefundOrder is the guarded entry point.
 // doc: https://revolut-engineering.github.io/api-docs/merchant-api/#backend-api-backend-api-order-object-refund-order
 func (a *OrderService) Refund(id string, refundReq *RefundReq) (*RefundResp, error) {
+	return a.RefundWithIdempotencyKey(id, refundReq, "")
+}
+
+// RefundWithIdempotencyKey refunds the order like Refund, a retry with the same idempotency key
+// returns the refund made by the first request instead of refunding again.
+func (a *OrderService) RefundWithIdempotencyKey(id string, refundReq *RefundReq, idempotencyKey string) (*RefundResp, error) {
+	resp, statusCode, err := request.New(request.Config{
+		Method:         http.MethodPost,
+		Url:            fmt.Sprintf("%s/api/1.0/orders/%s/refund", a.domain, id),
+		ApiKey:         a.apiKey,
+		Body:           refundReq,
+		ContentType:    request.ContentType_APPLICATION_JSON,
+		IdempotencyKey: idempotencyKey,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if statusCode != http.StatusOK {
+		return nil, errors.New(fmt.Sprintf("%s [status: %d]", string(resp), statusCode))
+	}
+
+	r := &RefundResp{}
+	if err := json.Unmarshal(resp, r); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// Update: Use this request to change an order which is not completed yet.
+// doc: https://developer.revolut.com/docs/merchant/update-order
+func (a *OrderService) Update(id string, orderUpdateReq *OrderUpdateReq) (*OrderResp, error) {
 	resp, statusCode, err := request.New(request.Config{
-		Method:      http.MethodPost,
-		Url:         fmt.Sprintf("%s/api/1.0/orders/%s/refund", a.domain, id),
+		Method:      http.MethodPatch,
+		Url:         fmt.Sprintf("%s/api/1.0/orders/%s", a.domain, id),
 		ApiKey:      a.apiKey,
-		Body:        refundReq,
+		Body:        orderUpdateReq,
 		ContentType: request.ContentType_APPLICATION_JSON,
 	})
 	if err != nil {
@@ -418,7 +588,7 @@ func (a *OrderService) Refund(id string, refundReq *RefundReq) (*RefundResp, err
 		return nil, errors.New(fmt.Sprintf("%s [status: %d]", string(resp), statusCode))
 	}
 
-	r := &RefundResp{}
+	r := &OrderResp{}
 	if err := json.Unmarshal(resp, r); err != nil {
 		return nil, err
 	}
@@ -0,0 +1,190 @@
+package merchant
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/adless-tech/go-revolut/merchant/1.0/request"
+)
+
+type OrderListReq struct {
+	// an optional number of records to return (1000 max, default is 100)
+	Limit int
+	// an optional timestamp to query to, filtering on the created_date field, it is used for pagination
+	CreatedBefore time.Time
+	// an optional timestamp to query from, filtering on the created_date field
+	FromCreatedDate time.Time
+	// an optional timestamp to query to, filtering on the created_date field
+	ToCreatedDate time.Time
+	// an optional customer ID
+	CustomerId string
+	// an optional customer e-mail
+	Email string
+	// an optional merchant order ID
+	MerchantOrderExtRef string
+	// an optional list of order states
+	State []OrderState
+}
+
+// List: Use this request to get a page of the orders matching the filters, the newest first.
+// A nil request lists without filters.
+// doc: https://developer.revolut.com/docs/merchant/retrieve-order-list
+func (a *OrderService) List(orderListReq *OrderListReq) ([]*OrderResp, error) {
+	if orderListReq == nil {
+		orderListReq = &OrderListReq{}
+	}
+
+	params := url.Values{}
+	if orderListReq.Limit != 0 {
+		params.Add("limit", fmt.Sprintf("%d", orderListReq.Limit))
+	}
+	if !orderListReq.CreatedBefore.IsZero() {
+		params.Add("created_before", orderListReq.CreatedBefore.UTC().Format(time.RFC3339Nano))
+	}
+	if !orderListReq.FromCreatedDate.IsZero() {
+		params.Add("from_created_date", orderListReq.FromCreatedDate.UTC().Format(time.RFC3339Nano))
+	}
+	if !orderListReq.ToCreatedDate.IsZero() {
+		params.Add("to_created_date", orderListReq.ToCreatedDate.UTC().Format(time.RFC3339Nano))
+	}
+	if orderListReq.CustomerId != "" {
+		params.Add("customer_id", orderListReq.CustomerId)
+	}
+	if orderListReq.Email != "" {
+		params.Add("email", orderListReq.Email)
+	}
+	if orderListReq.MerchantOrderExtRef != "" {
+		params.Add("merchant_order_ext_ref", orderListReq.MerchantOrderExtRef)
+	}
+	for _, state := range orderListReq.State {
+		params.Add("state", string(state))
+	}
+
+	resp, statusCode, err := request.New(request.Config{
+		Method: http.MethodGet,
+		Url:    fmt.Sprintf("%s/api/1.0/orders?%s", a.domain, params.Encode()),
+		ApiKey: a.apiKey,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if statusCode != http.StatusOK {
+		return nil, errors.New(fmt.Sprintf("%s [status: %d]", string(resp), statusCode))
+	}
+
+	r := []*OrderResp{}
+	if err := json.Unmarshal(resp, &r); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// Iterate returns an iterator over all the orders matching the filters, fetching the pages as needed.
+// The Limit of the request is the size of a page.
+func (a *OrderService) Iterate(orderListReq *OrderListReq) *OrderIterator {
+	return NewOrderIterator(a, orderListReq)
+}
+
+// NewOrderIterator returns an iterator over the orders listed by orders matching the filters,
+// a nil request iterates over all the orders.
+func NewOrderIterator(orders OrderAPI, orderListReq *OrderListReq) *OrderIterator {
+	req := OrderListReq{}
+	if orderListReq != nil {
+		req = *orderListReq
+	}
+	if req.Limit == 0 {
+		req.Limit = 100
+	}
+
+	return &OrderIterator{
+		service: orders,
+		req:     req,
+	}
+}
+
+// OrderIterator walks the pages of an order list.
+//
+//	it := mC.Order().Iterate(&merchant.OrderListReq{Email: "john@example.com"})
+//	for it.Next() {
+//		fmt.Println(it.Order())
+//	}
+//	if err := it.Err(); err != nil {
+//		panic(err)
+//	}
+type OrderIterator struct {
+	service OrderAPI
+	req     OrderListReq
+
+	page  []*OrderResp
+	order *OrderResp
+	// the orders of the last page created at its boundary, they come again on the next page
+	boundary map[string]bool
+	last     bool
+	err      error
+}
+
+// Next advances to the next order, it returns false at the end or on an error.
+func (it *OrderIterator) Next() bool {
+	for len(it.page) == 0 {
+		if it.last || it.err != nil {
+			return false
+		}
+		it.fetch()
+	}
+
+	it.order, it.page = it.page[0], it.page[1:]
+	return true
+}
+
+// Order returns the current order.
+func (it *OrderIterator) Order() *OrderResp {
+	return it.order
+}
+
+// Err returns the error which stopped the iteration.
+func (it *OrderIterator) Err() error {
+	return it.err
+}
+
+func (it *OrderIterator) fetch() {
+	page, err := it.service.List(&it.req)
+	if err != nil {
+		it.err = err
+		return
+	}
+	if len(page) < it.req.Limit {
+		it.last = true
+	}
+	if len(page) == 0 {
+		it.last = true
+		return
+	}
+
+	// the next page starts at the creation of the last order, inclusive,
+	// so the orders created in the same millisecond are not lost
+	created := page[len(page)-1].CreatedDate
+	it.req.CreatedBefore = time.Unix(0, (created+1)*int64(time.Millisecond))
+
+	boundary := map[string]bool{}
+	for _, order := range page {
+		if order.CreatedDate == created {
+			boundary[order.Id] = true
+		}
+		if it.boundary[order.Id] {
+			continue
+		}
+		it.page = append(it.page, order)
+	}
+
+	// a full page created in a single millisecond cannot move forward
+	if len(it.page) == 0 && !it.last {
+		it.err = errors.New("merchant: order list pagination does not advance, increase the page limit")
+	}
+	it.boundary = boundary
+}
@@ -0,0 +1,95 @@
+package merchant
+
+import (
+	"fmt"
+)
+
+var orderTransitions = map[OrderState][]OrderState{
+	OrderState_PENDING:    {OrderState_PROCESSING, OrderState_AUTHORISED, OrderState_COMPLETED, OrderState_CANCELLED, OrderState_FAILED},
+	OrderState_PROCESSING: {OrderState_PENDING, OrderState_AUTHORISED, OrderState_COMPLETED, OrderState_CANCELLED, OrderState_FAILED},
+	OrderState_AUTHORISED: {OrderState_PROCESSING, OrderState_COMPLETED, OrderState_CANCELLED, OrderState_FAILED},
+}
+
+// CanTransitionTo reports whether an order can move from the state to the other one.
+// A declined payment moves a processing order back to pending, so the customer can pay again.
+func (s OrderState) CanTransitionTo(to OrderState) bool {
+	for _, state := range orderTransitions[s] {
+		if state == to {
+			return true
+		}
+	}
+	return false
+}
+
+// IsTerminal reports whether the order will not change its state anymore.
+func (s OrderState) IsTerminal() bool {
+	switch s {
+	case OrderState_COMPLETED, OrderState_CANCELLED, OrderState_FAILED:
+		return true
+	}
+	return false
+}
+
+// CanCapture reports whether an order in the state can be captured.
+func (s OrderState) CanCapture() bool {
+	return s == OrderState_AUTHORISED
+}
+
+// CanCancel reports whether an order in the state can be cancelled.
+func (s OrderState) CanCancel() bool {
+	return s == OrderState_PENDING || s == OrderState_AUTHORISED
+}
+
+// CanRefund reports whether an order in the state can be refunded.
+func (s OrderState) CanRefund() bool {
+	return s == OrderState_COMPLETED
+}
+
+// CanConfirm reports whether an order in the state can be paid.
+func (s OrderState) CanConfirm() bool {
+	return s == OrderState_PENDING
+}
+
+// OrderStateError is returned by the guarded operations when the order state forbids them.
+type OrderStateError struct {
+	// the order ID
+	OrderId string
+	// capture, cancel or refund
+	Op string
+	// the state of the order
+	State OrderState
+}
+
+func (e *OrderStateError) Error() string {
+	return fmt.Sprintf("merchant: cannot %s order %s in state %s", e.Op, e.OrderId, e.State)
+}
+
+// CaptureOrder captures the order when its last known state allows it, otherwise it returns an *OrderStateError
+// without calling the API.
+func CaptureOrder(orders OrderAPI, order *OrderResp) (*OrderResp, error) {
+	if !order.State.CanCapture() {
+		return nil, &OrderStateError{OrderId: order.Id, Op: "capture", State: order.State}
+	}
+
+	return orders.Capture(order.Id)
+}
+
+// CancelOrder cancels the order when its last known state allows it, otherwise it returns an *OrderStateError
+// without calling the API.
+func CancelOrder(orders OrderAPI, order *OrderResp) (*OrderResp, error) {
+	if !order.State.CanCancel() {
+		return nil, &OrderStateError{OrderId: order.Id, Op: "cancel", State: order.State}
+	}
+
+	return orders.Cancel(order.Id)
+}
+
+// RefundOrder refunds the order when its last known state allows it, otherwise it returns an *OrderStateError
+// without calling the API.
+func RefundOrder(orders OrderAPI, order *OrderResp, refundReq *RefundReq, idempotencyKey string) (*RefundResp, error) {
+	if !order.State.CanRefund() {
+		return nil, &OrderStateError{OrderId: order.Id, Op: "refund", State: order.State}
+	}
+
+	return orders.RefundWithIdempotencyKey(order.Id, refundReq, idempotencyKey)
+}
@@ -0,0 +1,159 @@
+package merchant
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+)
+
+var ErrOverRefund = errors.New("merchant: refund exceeds the refundable amount")
+
+// RefundedTotal returns the minor amount refunded from the order, including the refunds
+// in progress which are already related to the order but not in refunded_amount yet.
+func (o *OrderResp) RefundedTotal() int {
+	related := 0
+	for _, r := range o.Related {
+		if r.Type == OrderType_REFUND {
+			related += r.Amount.Value
+		}
+	}
+
+	if related > o.RefundedAmount.Value {
+		return related
+	}
+	return o.RefundedAmount.Value
+}
+
+// RefundableAmount returns the minor amount of the order which can still be refunded,
+// zero when the state of the order does not allow a refund.
+func (o *OrderResp) RefundableAmount() int {
+	if !o.State.CanRefund() {
+		return 0
+	}
+
+	paid := o.CapturedAmount()
+	if paid == 0 {
+		paid = o.OrderAmount.Value
+	}
+
+	refundable := paid - o.RefundedTotal()
+	if refundable < 0 {
+		return 0
+	}
+
+	return refundable
+}
+
+// RefundIdempotencyKey derives the idempotency key of a refund, so a retried refund
+// is recognised by Revolut. Two refunds of the same amount from one order need different references.
+func RefundIdempotencyKey(orderId string, amount int, reference string) string {
+	sum := sha1.Sum([]byte(fmt.Sprintf("refund|%s|%d|%s", orderId, amount, reference)))
+	return hex.EncodeToString(sum[:])
+}
+
+type RefundResult struct {
+	// the refunded order
+	OrderId string
+	// the refunded minor amount
+	Amount int
+	// the created refund
+	Refund *RefundResp
+	// the error of the refund
+	Err error
+}
+
+// RefundManager refunds orders without refunding more than was paid.
+type RefundManager struct {
+	orders OrderAPI
+
+	// how often Wait checks the refund, default is 2 seconds
+	PollInterval time.Duration
+}
+
+func NewRefundManager(orders OrderAPI) *RefundManager {
+	return &RefundManager{
+		orders:       orders,
+		PollInterval: 2 * time.Second,
+	}
+}
+
+// Refund refunds amount of the order, zero amount refunds everything refundable.
+// The amount is checked against the refundable amount of the order before the refund is made
+// by RefundOrder, which returns an *OrderStateError for an order not in a refundable state,
+// and the idempotency key is derived from the order, the amount and the reference.
+func (m *RefundManager) Refund(orderId string, amount int, reference, description string) (*RefundResp, error) {
+	order, err := m.orders.WithId(orderId)
+	if err != nil {
+		return nil, err
+	}
+
+	refundable := order.RefundableAmount()
+	if amount == 0 {
+		amount = refundable
+	}
+	if order.State.CanRefund() && (amount <= 0 || amount > refundable) {
+		return nil, fmt.Errorf("%w: %d, refundable is %d", ErrOverRefund, amount, refundable)
+	}
+
+	return RefundOrder(m.orders, order, &RefundReq{
+		Amount:      amount,
+		Currency:    order.OrderAmount.Currency,
+		Description: description,
+	}, RefundIdempotencyKey(orderId, amount, reference))
+}
+
+// Wait checks the refund until it is completed, failed or cancelled, or the timeout passes.
+func (m *RefundManager) Wait(refundId string, timeout time.Duration) (*RefundResp, error) {
+	deadline := time.Now().Add(timeout)
+	pollInterval := m.PollInterval
+	if pollInterval <= 0 {
+		pollInterval = 2 * time.Second
+	}
+
+	for {
+		order, err := m.orders.WithId(refundId)
+		if err != nil {
+			return nil, err
+		}
+
+		refund := &RefundResp{
+			Id:                     order.Id,
+			Type:                   order.Type,
+			State:                  order.State,
+			CreatedDate:            order.CreatedDate,
+			UpdatedDate:            order.UpdatedDate,
+			CompletedDate:          order.CompletedDate,
+			OrderAmount:            order.OrderAmount,
+			MerchantCustomerExtRef: order.MerchantCustomerExtRef,
+			Email:                  order.Email,
+			Related:                order.Related,
+		}
+		switch refund.State {
+		case OrderState_COMPLETED, OrderState_FAILED, OrderState_CANCELLED:
+			return refund, nil
+		}
+
+		if time.Now().Add(pollInterval).After(deadline) {
+			return refund, fmt.Errorf("merchant: refund %s is still %s", refundId, refund.State)
+		}
+		time.Sleep(pollInterval)
+	}
+}
+
+// RefundAll fully refunds every order, the failures are reported in the results.
+func (m *RefundManager) RefundAll(orderIds []string, reference, description string) []*RefundResult {
+	results := make([]*RefundResult, 0, len(orderIds))
+
+	for _, orderId := range orderIds {
+		result := &RefundResult{OrderId: orderId}
+		result.Refund, result.Err = m.Refund(orderId, 0, reference, description)
+		if result.Refund != nil {
+			result.Amount = result.Refund.OrderAmount.Value
+		}
+		results = append(results, result)
+	}
+
+	return results
+}
@@ -6,6 +6,9 @@ import (
 	"fmt"
 	"io/ioutil"
 	"net/http"
+	"time"
+
+	"github.com/adless-tech/go-revolut/logging"
 )
 
 type Config struct {
@@ -17,6 +20,13 @@ type Config struct {
 	IdempotencyKey string
 }
 
+// Transport sends the requests of all the clients, nil uses http.DefaultTransport.
+// Tests set it to a cassette.Recorder or a cassette.Player.
+var Transport http.RoundTripper
+
+// Logger is an optional logger of all the calls.
+var Logger *logging.Logger
+
 type ContentType string
 
 const (
@@ -46,17 +56,36 @@ func New(conf Config) ([]byte, int, error) {
 		req.Header.Set("x-idempotency-key", conf.IdempotencyKey)
 	}
 
-	c := &http.Client{}
+	c := &http.Client{Transport: Transport}
 
+	start := time.Now()
 	resp, err := c.Do(req)
 	if err != nil {
+		logCall(req, conf.IdempotencyKey, b, nil, 0, start, err)
 		return []byte{}, 0, err
 	}
+	defer resp.Body.Close()
 
-	b, err = ioutil.ReadAll(resp.Body)
+	respBody, err := ioutil.ReadAll(resp.Body)
+	logCall(req, conf.IdempotencyKey, b, respBody, resp.StatusCode, start, err)
 	if err != nil {
 		return []byte{}, 0, err
 	}
 
-	return b, resp.StatusCode, nil
+	return respBody, resp.StatusCode, nil
+}
+
+func logCall(req *http.Request, requestId string, requestBody, responseBody []byte, status int, start time.Time, err error) {
+	if Logger == nil {
+		return
+	}
+
+	Logger.Log(&logging.Record{
+		Method:    req.Method,
+		Path:      req.URL.Path,
+		Status:    status,
+		Latency:   time.Since(start),
+		RequestId: requestId,
+		Err:       err,
+	}, requestBody, responseBody)
 }
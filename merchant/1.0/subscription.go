@@ -0,0 +1,508 @@
+package merchant
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+)
+
+type IntervalUnit string
+
+const (
+	IntervalUnit_DAY   IntervalUnit = "day"
+	IntervalUnit_WEEK  IntervalUnit = "week"
+	IntervalUnit_MONTH IntervalUnit = "month"
+	IntervalUnit_YEAR  IntervalUnit = "year"
+)
+
+// Interval is a billing interval, e.g. every 3 months.
+type Interval struct {
+	Unit IntervalUnit
+	// the number of units, default is 1
+	Count int
+}
+
+// Add adds n intervals to t. Months keep the day of t when the month has it,
+// otherwise they end on the last day of the month, so Jan 31 is followed by Feb 28 and Mar 31.
+func (i Interval) Add(t time.Time, n int) time.Time {
+	count := i.Count
+	if count == 0 {
+		count = 1
+	}
+
+	switch i.Unit {
+	case IntervalUnit_DAY:
+		return t.AddDate(0, 0, n*count)
+	case IntervalUnit_WEEK:
+		return t.AddDate(0, 0, 7*n*count)
+	case IntervalUnit_YEAR:
+		return addMonths(t, 12*n*count)
+	}
+	return addMonths(t, n*count)
+}
+
+func addMonths(t time.Time, months int) time.Time {
+	y, m, d := t.Date()
+	if last := time.Date(y, m+time.Month(months)+1, 0, 0, 0, 0, 0, t.Location()).Day(); d > last {
+		d = last
+	}
+
+	return time.Date(y, m+time.Month(months), d, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), t.Location())
+}
+
+type Plan struct {
+	// the unique ID of the plan
+	Id string
+	// the plan name, it is the description of the orders
+	Name string
+	// minor amount charged every interval
+	Amount int
+	// currency code
+	Currency string
+	Interval Interval
+}
+
+type SubscriptionStatus string
+
+const (
+	// the subscription is paid and billed every interval
+	SubscriptionStatus_ACTIVE SubscriptionStatus = "ACTIVE"
+	// the last charge failed and is retried
+	SubscriptionStatus_PAST_DUE SubscriptionStatus = "PAST_DUE"
+	// all the retries failed, the subscription is not billed anymore
+	SubscriptionStatus_UNPAID SubscriptionStatus = "UNPAID"
+	// the subscription was cancelled
+	SubscriptionStatus_CANCELLED SubscriptionStatus = "CANCELLED"
+)
+
+type Subscription struct {
+	Id string `json:"id"`
+	// the customer ID, see CustomerService
+	CustomerId string `json:"customer_id"`
+	// an optional payment method saved for the merchant, default is the first one
+	PaymentMethodId string             `json:"payment_method_id,omitempty"`
+	PlanId          string             `json:"plan_id"`
+	Status          SubscriptionStatus `json:"status"`
+	// the start of the first period, the periods are counted from it
+	AnchorAt time.Time `json:"anchor_at"`
+	// the number of the period billed next, starting at 0
+	Period int `json:"period"`
+	// the paid period
+	CurrentPeriodStart time.Time `json:"current_period_start,omitempty"`
+	CurrentPeriodEnd   time.Time `json:"current_period_end,omitempty"`
+	// when the next charge is made
+	NextAttemptAt time.Time `json:"next_attempt_at"`
+	// the failed charges of the period billed next
+	Attempts int `json:"attempts,omitempty"`
+	// the minor amount added to the next charge by plan changes, negative is a credit
+	Adjustment int `json:"adjustment,omitempty"`
+	// the part of Adjustment charged by the pending order
+	PendingAdjustment int `json:"pending_adjustment,omitempty"`
+	// the order of the charge in progress
+	PendingOrderId string `json:"pending_order_id,omitempty"`
+	// the order of the last finished charge
+	LastOrderId string `json:"last_order_id,omitempty"`
+	// cancel the subscription instead of the next charge
+	CancelAtPeriodEnd bool      `json:"cancel_at_period_end,omitempty"`
+	CreatedAt         time.Time `json:"created_at"`
+	UpdatedAt         time.Time `json:"updated_at"`
+}
+
+func (s *Subscription) due(now time.Time) bool {
+	if s.Status != SubscriptionStatus_ACTIVE && s.Status != SubscriptionStatus_PAST_DUE {
+		return false
+	}
+
+	return s.PendingOrderId != "" || !s.NextAttemptAt.After(now)
+}
+
+// DefaultDunningBackoff are the delays of the retries after a failed charge.
+var DefaultDunningBackoff = []time.Duration{24 * time.Hour, 3 * 24 * time.Hour, 5 * 24 * time.Hour, 7 * 24 * time.Hour}
+
+type SubscriptionCharge struct {
+	// the subscription after the charge
+	Subscription *Subscription
+	// the start of the billed period
+	Period time.Time
+	// the charged minor amount
+	Amount int
+	// the order of the charge
+	Order *OrderResp
+	// the error of the charge, a *DeclineError for a declined payment
+	Err error
+}
+
+// Biller bills the subscriptions by charging their saved payment methods every interval.
+// The orders are paid asynchronously, their outcome is taken from the order web-hooks
+// passed to HandleEvent, or from the order itself on the next run.
+type Biller struct {
+	orders  OrderAPI
+	charger *Charger
+	store   SubscriptionStore
+	plans   map[string]*Plan
+
+	mu sync.Mutex
+
+	// the delays of the retries after a failed charge, default is DefaultDunningBackoff
+	Backoff []time.Duration
+	// an optional callback for every charge made by the scheduler
+	OnCharge func(charge *SubscriptionCharge)
+	// an optional callback for the ticks of the scheduler which failed, it gets the first error of the tick
+	OnError func(err error)
+}
+
+func NewBiller(client API, store SubscriptionStore, plans ...*Plan) (*Biller, error) {
+	b := &Biller{
+		orders:  client.Order(),
+		charger: NewCharger(client.Order(), client.Customer()),
+		store:   store,
+		plans:   map[string]*Plan{},
+		Backoff: DefaultDunningBackoff,
+	}
+
+	for _, plan := range plans {
+		if plan.Id == "" || b.plans[plan.Id] != nil {
+			return nil, fmt.Errorf("subscription: plan id %q is empty or not unique", plan.Id)
+		}
+		if plan.Amount <= 0 || plan.Currency == "" {
+			return nil, fmt.Errorf("subscription: plan %s needs an amount and a currency", plan.Id)
+		}
+		switch plan.Interval.Unit {
+		case IntervalUnit_DAY, IntervalUnit_WEEK, IntervalUnit_MONTH, IntervalUnit_YEAR:
+		default:
+			return nil, fmt.Errorf("subscription: plan %s has unknown interval unit %q", plan.Id, plan.Interval.Unit)
+		}
+		if plan.Interval.Count < 0 {
+			return nil, fmt.Errorf("subscription: plan %s has negative interval count", plan.Id)
+		}
+		b.plans[plan.Id] = plan
+	}
+
+	return b, nil
+}
+
+// Subscribe subscribes the customer to the plan, the first period is charged at start.
+func (b *Biller) Subscribe(customerId, paymentMethodId, planId string, start time.Time) (*Subscription, error) {
+	if b.plans[planId] == nil {
+		return nil, fmt.Errorf("subscription: unknown plan %s", planId)
+	}
+
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	sub := &Subscription{
+		Id:              hex.EncodeToString(id),
+		CustomerId:      customerId,
+		PaymentMethodId: paymentMethodId,
+		PlanId:          planId,
+		Status:          SubscriptionStatus_ACTIVE,
+		AnchorAt:        start,
+		NextAttemptAt:   start,
+		CreatedAt:       now,
+		UpdatedAt:       now,
+	}
+	if err := b.store.Save(sub); err != nil {
+		return nil, err
+	}
+
+	return sub, nil
+}
+
+// ChangePlan moves the subscription to another plan of the same currency at now.
+// The unused part of the paid period is credited at the old price and charged at the new one
+// with the next charge, and a plan with another interval starts its periods at the end of the paid one.
+func (b *Biller) ChangePlan(id, planId string, now time.Time) (*Subscription, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	sub, err := b.store.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	if sub.Status != SubscriptionStatus_ACTIVE && sub.Status != SubscriptionStatus_PAST_DUE {
+		return nil, fmt.Errorf("subscription: %s is %s", id, sub.Status)
+	}
+
+	from, to := b.plans[sub.PlanId], b.plans[planId]
+	if to == nil {
+		return nil, fmt.Errorf("subscription: unknown plan %s", planId)
+	}
+	if from == nil {
+		return nil, fmt.Errorf("subscription: unknown plan %s", sub.PlanId)
+	}
+	if from.Currency != to.Currency {
+		return nil, fmt.Errorf("subscription: plans %s and %s are in different currencies", from.Id, to.Id)
+	}
+
+	if now.After(sub.CurrentPeriodStart) && now.Before(sub.CurrentPeriodEnd) {
+		unused := float64(sub.CurrentPeriodEnd.Sub(now)) / float64(sub.CurrentPeriodEnd.Sub(sub.CurrentPeriodStart))
+		sub.Adjustment += int(math.Round(float64(to.Amount)*unused)) - int(math.Round(float64(from.Amount)*unused))
+	}
+
+	if from.Interval != to.Interval {
+		sub.AnchorAt = from.Interval.Add(sub.AnchorAt, sub.Period)
+		sub.Period = 0
+	}
+	sub.PlanId = to.Id
+	sub.UpdatedAt = time.Now()
+
+	if err := b.store.Save(sub); err != nil {
+		return nil, err
+	}
+
+	return sub, nil
+}
+
+// Cancel cancels the subscription now, or at the end of the paid period.
+func (b *Biller) Cancel(id string, atPeriodEnd bool) (*Subscription, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	sub, err := b.store.Get(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if atPeriodEnd {
+		sub.CancelAtPeriodEnd = true
+	} else {
+		sub.Status = SubscriptionStatus_CANCELLED
+	}
+	sub.UpdatedAt = time.Now()
+
+	if err := b.store.Save(sub); err != nil {
+		return nil, err
+	}
+
+	return sub, nil
+}
+
+// RunOnce charges the subscriptions due at now and checks the pending orders.
+// It returns the charges together with the first error.
+func (b *Biller) RunOnce(now time.Time) ([]*SubscriptionCharge, error) {
+	due, err := b.store.Due(now)
+	if err != nil {
+		return nil, err
+	}
+
+	var charges []*SubscriptionCharge
+	var firstErr error
+	for _, s := range due {
+		charge, err := b.bill(s.Id, now)
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+		if charge != nil {
+			charges = append(charges, charge)
+		}
+	}
+
+	return charges, firstErr
+}
+
+// Run bills the subscriptions every tick until ctx is done, the billing errors are reported by OnError.
+func (b *Biller) Run(ctx context.Context, tick time.Duration) error {
+	if tick <= 0 {
+		return fmt.Errorf("subscription: tick %s is not positive", tick)
+	}
+
+	ticker := time.NewTicker(tick)
+	defer ticker.Stop()
+
+	for {
+		charges, err := b.RunOnce(time.Now())
+		for _, charge := range charges {
+			if b.OnCharge != nil {
+				b.OnCharge(charge)
+			}
+		}
+		if err != nil && b.OnError != nil {
+			b.OnError(err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// HandleEvent applies an order web-hook event to the subscription the order is pending for,
+// it is an OrderEventFunc for WebhookHandler. The events of other orders are ignored.
+func (b *Biller) HandleEvent(event *WebhookResp, order *OrderResp) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	sub, err := b.store.ByOrder(event.OrderId)
+	if errors.Is(err, ErrSubscriptionNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if order == nil {
+		if order, err = b.orders.WithId(event.OrderId); err != nil {
+			return err
+		}
+	}
+
+	if !b.apply(sub, order, time.Now()) {
+		return nil
+	}
+
+	return b.store.Save(sub)
+}
+
+func (b *Biller) bill(id string, now time.Time) (*SubscriptionCharge, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	sub, err := b.store.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	if !sub.due(now) {
+		return nil, nil
+	}
+
+	plan := b.plans[sub.PlanId]
+	if plan == nil {
+		return nil, fmt.Errorf("subscription: %s has unknown plan %s", sub.Id, sub.PlanId)
+	}
+	charge := &SubscriptionCharge{
+		Subscription: sub,
+		Period:       plan.Interval.Add(sub.AnchorAt, sub.Period),
+	}
+
+	if sub.PendingOrderId != "" {
+		order, err := b.orders.WithId(sub.PendingOrderId)
+		if err != nil {
+			return nil, err
+		}
+		if !b.apply(sub, order, now) {
+			return nil, nil
+		}
+		charge.Order = order
+		charge.Amount = order.OrderAmount.Value
+		return charge, b.store.Save(sub)
+	}
+
+	if sub.CancelAtPeriodEnd {
+		sub.Status = SubscriptionStatus_CANCELLED
+		sub.UpdatedAt = time.Now()
+		return nil, b.store.Save(sub)
+	}
+
+	charge.Amount = plan.Amount + sub.Adjustment
+	if charge.Amount <= 0 {
+		// the credit covers the period, the rest of it is carried over
+		sub.PendingAdjustment = -plan.Amount
+		b.paid(sub, "")
+		charge.Amount = 0
+		return charge, b.store.Save(sub)
+	}
+	sub.PendingAdjustment = sub.Adjustment
+
+	key := sha1.Sum([]byte(fmt.Sprintf("subscription|%s|%d|%d|%d", sub.Id, sub.AnchorAt.Unix(), sub.Period, sub.Attempts)))
+	order, err := b.charger.Charge(&OrderReq{
+		Amount:      charge.Amount,
+		Currency:    plan.Currency,
+		CustomerID:  sub.CustomerId,
+		Description: plan.Name,
+		Metadata: map[string]string{
+			"subscription_id": sub.Id,
+			"period":          charge.Period.Format(time.RFC3339),
+		},
+	}, sub.PaymentMethodId, hex.EncodeToString(key[:]))
+	charge.Order, charge.Err = order, err
+
+	switch {
+	case order != nil:
+		sub.PendingOrderId = order.Id
+		b.apply(sub, order, now)
+	case errors.Is(err, ErrNoPaymentMethod):
+		b.failed(sub, "", now)
+	default:
+		// the charge is retried on the next run
+		return charge, err
+	}
+
+	if err := b.store.Save(sub); err != nil {
+		return charge, err
+	}
+
+	return charge, charge.Err
+}
+
+// apply updates the subscription by the state of its pending order, it reports whether the order finished.
+func (b *Biller) apply(sub *Subscription, order *OrderResp, now time.Time) bool {
+	if order.Id != sub.PendingOrderId {
+		return false
+	}
+
+	switch order.State {
+	case OrderState_AUTHORISED, OrderState_COMPLETED:
+		b.paid(sub, order.Id)
+		return true
+	case OrderState_FAILED, OrderState_CANCELLED:
+		b.failed(sub, order.Id, now)
+		return true
+	}
+
+	if payment := order.LastPayment(); payment != nil && payment.Err(order.Id) != nil {
+		b.failed(sub, order.Id, now)
+		return true
+	}
+
+	return false
+}
+
+func (b *Biller) paid(sub *Subscription, orderId string) {
+	plan := b.plans[sub.PlanId]
+
+	sub.CurrentPeriodStart = plan.Interval.Add(sub.AnchorAt, sub.Period)
+	sub.CurrentPeriodEnd = plan.Interval.Add(sub.AnchorAt, sub.Period+1)
+	sub.Period++
+	sub.NextAttemptAt = sub.CurrentPeriodEnd
+	sub.Attempts = 0
+	sub.Adjustment -= sub.PendingAdjustment
+	sub.PendingAdjustment = 0
+	sub.PendingOrderId = ""
+	if orderId != "" {
+		sub.LastOrderId = orderId
+	}
+	sub.Status = SubscriptionStatus_ACTIVE
+	sub.UpdatedAt = time.Now()
+}
+
+func (b *Biller) failed(sub *Subscription, orderId string, now time.Time) {
+	backoff := b.Backoff
+	if backoff == nil {
+		backoff = DefaultDunningBackoff
+	}
+
+	sub.Attempts++
+	sub.PendingAdjustment = 0
+	sub.PendingOrderId = ""
+	if orderId != "" {
+		sub.LastOrderId = orderId
+	}
+	if sub.Attempts > len(backoff) {
+		sub.Status = SubscriptionStatus_UNPAID
+	} else {
+		sub.Status = SubscriptionStatus_PAST_DUE
+		sub.NextAttemptAt = now.Add(backoff[sub.Attempts-1])
+	}
+	sub.UpdatedAt = time.Now()
+}
@@ -0,0 +1,87 @@
+package merchant
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"time"
+)
+
+var ErrSubscriptionNotFound = errors.New("merchant: subscription not found")
+
+// SubscriptionStore keeps the subscriptions of the Biller.
+type SubscriptionStore interface {
+	// Save creates or replaces the subscription
+	Save(sub *Subscription) error
+	// Get returns the subscription, or ErrSubscriptionNotFound
+	Get(id string) (*Subscription, error)
+	// ByOrder returns the subscription the order is pending for, or ErrSubscriptionNotFound
+	ByOrder(orderId string) (*Subscription, error)
+	// Due returns the subscriptions with a pending order or an attempt due at now
+	Due(now time.Time) ([]*Subscription, error)
+}
+
+// MemorySubscriptionStore keeps copies of the subscriptions in memory.
+type MemorySubscriptionStore struct {
+	mu   sync.RWMutex
+	subs map[string]*Subscription
+}
+
+func NewMemorySubscriptionStore() *MemorySubscriptionStore {
+	return &MemorySubscriptionStore{subs: map[string]*Subscription{}}
+}
+
+func (s *MemorySubscriptionStore) Save(sub *Subscription) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c := *sub
+	s.subs[sub.Id] = &c
+
+	return nil
+}
+
+func (s *MemorySubscriptionStore) Get(id string) (*Subscription, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	sub, ok := s.subs[id]
+	if !ok {
+		return nil, ErrSubscriptionNotFound
+	}
+
+	c := *sub
+	return &c, nil
+}
+
+func (s *MemorySubscriptionStore) ByOrder(orderId string) (*Subscription, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, sub := range s.subs {
+		if sub.PendingOrderId == orderId {
+			c := *sub
+			return &c, nil
+		}
+	}
+
+	return nil, ErrSubscriptionNotFound
+}
+
+func (s *MemorySubscriptionStore) Due(now time.Time) ([]*Subscription, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var r []*Subscription
+	for _, sub := range s.subs {
+		if sub.due(now) {
+			c := *sub
+			r = append(r, &c)
+		}
+	}
+	sort.Slice(r, func(i, j int) bool {
+		return r[i].NextAttemptAt.Before(r[j].NextAttemptAt)
+	})
+
+	return r, nil
+}
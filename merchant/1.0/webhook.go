@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"time"
 
 	"github.com/adless-tech/go-revolut/merchant/1.0/request"
 )
@@ -14,14 +15,50 @@ type WebhookService struct {
 	domain string
 }
 
+type WebhookEvent string
+
+const (
+	WebhookEvent_ORDER_COMPLETED             WebhookEvent = "ORDER_COMPLETED"
+	WebhookEvent_ORDER_AUTHORISED            WebhookEvent = "ORDER_AUTHORISED"
+	WebhookEvent_ORDER_CANCELLED             WebhookEvent = "ORDER_CANCELLED"
+	WebhookEvent_ORDER_PAYMENT_AUTHENTICATED WebhookEvent = "ORDER_PAYMENT_AUTHENTICATED"
+	WebhookEvent_ORDER_PAYMENT_DECLINED      WebhookEvent = "ORDER_PAYMENT_DECLINED"
+	WebhookEvent_ORDER_PAYMENT_FAILED        WebhookEvent = "ORDER_PAYMENT_FAILED"
+	WebhookEvent_PAYOUT_INITIATED            WebhookEvent = "PAYOUT_INITIATED"
+	WebhookEvent_PAYOUT_COMPLETED            WebhookEvent = "PAYOUT_COMPLETED"
+	WebhookEvent_PAYOUT_FAILED               WebhookEvent = "PAYOUT_FAILED"
+)
+
 type WebhookUrl struct {
 	// call back endpoint of the client system, https is the supported protocol
 	Url string `json:"url,omitempty"`
 }
 
+type WebhookReq struct {
+	// call back endpoint of the client system, https is the supported protocol
+	Url string `json:"url,omitempty"`
+	// the list of events the web-hook is subscribed to
+	Events []WebhookEvent `json:"events,omitempty"`
+}
+
+type Webhook struct {
+	// Webhook ID
+	Id string `json:"id,omitempty"`
+	// call back endpoint of the client system
+	Url string `json:"url,omitempty"`
+	// the list of events the web-hook is subscribed to
+	Events []WebhookEvent `json:"events,omitempty"`
+	// the signing secret of the web-hook, not returned by List
+	SigningSecret string `json:"signing_secret,omitempty"`
+}
+
 type WebhookResp struct {
-	// Order ID of a completed order
+	// the event name
+	Event WebhookEvent `json:"event,omitempty"`
+	// Order ID of the order the event is about
 	OrderId string `json:"order_id,omitempty"`
+	// Merchant order ID
+	MerchantOrderExtRef string `json:"merchant_order_ext_ref,omitempty"`
 }
 
 // Set:
@@ -30,7 +67,7 @@ func (w *WebhookService) Set(webhookReq *WebhookUrl) error {
 
 	resp, statusCode, err := request.New(request.Config{
 		Method:      http.MethodPost,
-		Url:         "https://merchant.revolut.com/api/1.0/webhooks",
+		Url:         fmt.Sprintf("%s/api/1.0/webhooks", w.domain),
 		ApiKey:      w.apiKey,
 		Body:        webhookReq,
 		ContentType: request.ContentType_APPLICATION_JSON,
@@ -38,16 +75,41 @@ func (w *WebhookService) Set(webhookReq *WebhookUrl) error {
 	if err != nil {
 		return err
 	}
-	if statusCode != http.StatusNoContent {
-		return errors.New(string(resp))
+	if statusCode != http.StatusNoContent && statusCode != http.StatusOK && statusCode != http.StatusCreated {
+		return errors.New(fmt.Sprintf("%s [status: %d]", string(resp), statusCode))
 	}
 
 	return nil
 }
 
+// Create: Use this request to create a web-hook subscribed to the given events.
+// doc: https://developer.revolut.com/docs/merchant/create-webhook
+func (w *WebhookService) Create(webhookReq *WebhookReq) (*Webhook, error) {
+	resp, statusCode, err := request.New(request.Config{
+		Method:      http.MethodPost,
+		Url:         fmt.Sprintf("%s/api/1.0/webhooks", w.domain),
+		ApiKey:      w.apiKey,
+		Body:        webhookReq,
+		ContentType: request.ContentType_APPLICATION_JSON,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if statusCode != http.StatusOK && statusCode != http.StatusCreated {
+		return nil, errors.New(fmt.Sprintf("%s [status: %d]", string(resp), statusCode))
+	}
+
+	r := &Webhook{}
+	if err := json.Unmarshal(resp, r); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
 // List:
 // doc: https://revolut-engineering.github.io/api-docs/merchant-api/#backend-api-backend-api-webhooks-retrieve-webhooks
-func (w *WebhookService) List() ([]*WebhookUrl, error) {
+func (w *WebhookService) List() ([]*Webhook, error) {
 
 	resp, statusCode, err := request.New(request.Config{
 		Method: http.MethodGet,
@@ -58,10 +120,10 @@ func (w *WebhookService) List() ([]*WebhookUrl, error) {
 		return nil, err
 	}
 	if statusCode != http.StatusOK {
-		return nil, errors.New(string(resp))
+		return nil, errors.New(fmt.Sprintf("%s [status: %d]", string(resp), statusCode))
 	}
 
-	r := []*WebhookUrl{}
+	r := []*Webhook{}
 	if err := json.Unmarshal(resp, &r); err != nil {
 		return nil, err
 	}
@@ -69,22 +131,102 @@ func (w *WebhookService) List() ([]*WebhookUrl, error) {
 	return r, nil
 }
 
-//// Delete: Use this API request to delete a web-hook
-//// doc: https://revolut-engineering.github.io/api-docs/business-api/#web-hooks-setting-up-a-web-hook
-//func (w *WebhookService) Delete() error {
-//
-//	resp, statusCode, err := request.New(request.Config{
-//		Method:      http.MethodDelete,
-//		Url:         "https://merchant.revolut.com/api/1.0/webhook",
-//		AccessToken: p.accessToken,
-//		Sandbox:     p.sandbox,
-//	})
-//	if err != nil {
-//		return err
-//	}
-//	if statusCode != http.StatusNoContent {
-//		return errors.New(string(resp))
-//	}
-//
-//	return nil
-//}
+// WithId: Use this request to get a web-hook with its signing secret.
+// doc: https://developer.revolut.com/docs/merchant/retrieve-webhook
+func (w *WebhookService) WithId(id string) (*Webhook, error) {
+	resp, statusCode, err := request.New(request.Config{
+		Method: http.MethodGet,
+		Url:    fmt.Sprintf("%s/api/1.0/webhooks/%s", w.domain, id),
+		ApiKey: w.apiKey,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if statusCode != http.StatusOK {
+		return nil, errors.New(fmt.Sprintf("%s [status: %d]", string(resp), statusCode))
+	}
+
+	r := &Webhook{}
+	if err := json.Unmarshal(resp, r); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// Update: Use this request to change the url or the events of a web-hook.
+// doc: https://developer.revolut.com/docs/merchant/update-webhook
+func (w *WebhookService) Update(id string, webhookReq *WebhookReq) (*Webhook, error) {
+	resp, statusCode, err := request.New(request.Config{
+		Method:      http.MethodPut,
+		Url:         fmt.Sprintf("%s/api/1.0/webhooks/%s", w.domain, id),
+		ApiKey:      w.apiKey,
+		Body:        webhookReq,
+		ContentType: request.ContentType_APPLICATION_JSON,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if statusCode != http.StatusOK {
+		return nil, errors.New(fmt.Sprintf("%s [status: %d]", string(resp), statusCode))
+	}
+
+	r := &Webhook{}
+	if err := json.Unmarshal(resp, r); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// Delete: Use this request to delete a web-hook.
+// doc: https://developer.revolut.com/docs/merchant/delete-webhook
+func (w *WebhookService) Delete(id string) error {
+	resp, statusCode, err := request.New(request.Config{
+		Method: http.MethodDelete,
+		Url:    fmt.Sprintf("%s/api/1.0/webhooks/%s", w.domain, id),
+		ApiKey: w.apiKey,
+	})
+	if err != nil {
+		return err
+	}
+	if statusCode != http.StatusNoContent && statusCode != http.StatusOK {
+		return errors.New(fmt.Sprintf("%s [status: %d]", string(resp), statusCode))
+	}
+
+	return nil
+}
+
+// RotateSigningSecret: Use this request to issue a new signing secret for a web-hook.
+// The old secret stays valid for expirationPeriod (7 days max), zero revokes it immediately.
+// doc: https://developer.revolut.com/docs/merchant/rotate-webhook-signing-secret
+func (w *WebhookService) RotateSigningSecret(id string, expirationPeriod time.Duration) (*Webhook, error) {
+	body := struct {
+		// the period the old secret stays valid for, ISO 8601 duration
+		ExpirationPeriod string `json:"expiration_period,omitempty"`
+	}{}
+	if expirationPeriod > 0 {
+		body.ExpirationPeriod = fmt.Sprintf("PT%dS", int64(expirationPeriod/time.Second))
+	}
+
+	resp, statusCode, err := request.New(request.Config{
+		Method:      http.MethodPost,
+		Url:         fmt.Sprintf("%s/api/1.0/webhooks/%s/rotate-signing-secret", w.domain, id),
+		ApiKey:      w.apiKey,
+		Body:        body,
+		ContentType: request.ContentType_APPLICATION_JSON,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if statusCode != http.StatusOK {
+		return nil, errors.New(fmt.Sprintf("%s [status: %d]", string(resp), statusCode))
+	}
+
+	r := &Webhook{}
+	if err := json.Unmarshal(resp, r); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
@@ -0,0 +1,170 @@
+package merchant
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/adless-tech/go-revolut/webhook"
+)
+
+// DefaultWebhookMaxBodySize is the largest event body accepted by WebhookHandler, 1 MB.
+const DefaultWebhookMaxBodySize = 1 << 20
+
+// OrderEventFunc is a callback of an order event. order is set only when
+// the handler fetches the orders, see WebhookHandler.FetchOrder.
+type OrderEventFunc func(event *WebhookResp, order *OrderResp) error
+
+// WebhookHandler is an http.Handler receiving the order events and
+// dispatching them to the registered callbacks. A callback error is answered
+// with 500, so Revolut delivers the event again.
+type WebhookHandler struct {
+	// the largest accepted body, default is DefaultWebhookMaxBodySize
+	MaxBodySize int64
+
+	verifier       *webhook.Verifier
+	orders         OrderAPI
+	callbacks      map[WebhookEvent]OrderEventFunc
+	onUnknownEvent OrderEventFunc
+}
+
+type webhookDecodeError struct {
+	err error
+}
+
+func (e *webhookDecodeError) Error() string {
+	return "webhook: malformed event: " + e.err.Error()
+}
+
+func NewWebhookHandler() *WebhookHandler {
+	return &WebhookHandler{
+		MaxBodySize: DefaultWebhookMaxBodySize,
+		callbacks:   map[WebhookEvent]OrderEventFunc{},
+	}
+}
+
+// Verify makes the handler check the signature of every event with the verifier.
+func (h *WebhookHandler) Verify(verifier *webhook.Verifier) *WebhookHandler {
+	h.verifier = verifier
+	return h
+}
+
+// FetchOrder makes the handler retrieve the order of every event before calling the callback,
+// so the callback gets the authoritative state instead of trusting the event.
+func (h *WebhookHandler) FetchOrder(orders OrderAPI) *WebhookHandler {
+	h.orders = orders
+	return h
+}
+
+// On registers the callback of the event.
+func (h *WebhookHandler) On(event WebhookEvent, fn OrderEventFunc) *WebhookHandler {
+	h.callbacks[event] = fn
+	return h
+}
+
+// OnOrderCompleted registers the callback of the ORDER_COMPLETED event.
+func (h *WebhookHandler) OnOrderCompleted(fn OrderEventFunc) *WebhookHandler {
+	return h.On(WebhookEvent_ORDER_COMPLETED, fn)
+}
+
+// OnOrderAuthorised registers the callback of the ORDER_AUTHORISED event.
+func (h *WebhookHandler) OnOrderAuthorised(fn OrderEventFunc) *WebhookHandler {
+	return h.On(WebhookEvent_ORDER_AUTHORISED, fn)
+}
+
+// OnOrderCancelled registers the callback of the ORDER_CANCELLED event.
+func (h *WebhookHandler) OnOrderCancelled(fn OrderEventFunc) *WebhookHandler {
+	return h.On(WebhookEvent_ORDER_CANCELLED, fn)
+}
+
+// OnOrderPaymentDeclined registers the callback of the ORDER_PAYMENT_DECLINED event.
+func (h *WebhookHandler) OnOrderPaymentDeclined(fn OrderEventFunc) *WebhookHandler {
+	return h.On(WebhookEvent_ORDER_PAYMENT_DECLINED, fn)
+}
+
+// OnOrderPaymentFailed registers the callback of the ORDER_PAYMENT_FAILED event.
+func (h *WebhookHandler) OnOrderPaymentFailed(fn OrderEventFunc) *WebhookHandler {
+	return h.On(WebhookEvent_ORDER_PAYMENT_FAILED, fn)
+}
+
+// OnUnknownEvent registers the callback of the events without a callback of their own.
+// Such events are acknowledged and dropped when it is not set.
+func (h *WebhookHandler) OnUnknownEvent(fn OrderEventFunc) *WebhookHandler {
+	h.onUnknownEvent = fn
+	return h
+}
+
+func (h *WebhookHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	if h.verifier != nil {
+		h.verifier.Middleware(http.HandlerFunc(h.serve)).ServeHTTP(w, r)
+		return
+	}
+
+	h.serve(w, r)
+}
+
+func (h *WebhookHandler) serve(w http.ResponseWriter, r *http.Request) {
+	maxBodySize := h.MaxBodySize
+	if maxBodySize <= 0 {
+		maxBodySize = DefaultWebhookMaxBodySize
+	}
+
+	body, err := ioutil.ReadAll(io.LimitReader(r.Body, maxBodySize+1))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if int64(len(body)) > maxBodySize {
+		http.Error(w, http.StatusText(http.StatusRequestEntityTooLarge), http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	if err := h.Dispatch(body); err != nil {
+		var decodeErr *webhookDecodeError
+		if errors.As(err, &decodeErr) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// Dispatch decodes the event body, fetches the order when enabled and calls the registered callback.
+func (h *WebhookHandler) Dispatch(body []byte) error {
+	event := &WebhookResp{}
+	if err := json.Unmarshal(body, event); err != nil {
+		return &webhookDecodeError{err}
+	}
+	if event.Event == "" {
+		return &webhookDecodeError{errors.New("missing event")}
+	}
+
+	fn, ok := h.callbacks[event.Event]
+	if !ok {
+		fn = h.onUnknownEvent
+	}
+	if fn == nil {
+		return nil
+	}
+
+	var order *OrderResp
+	if h.orders != nil && event.OrderId != "" {
+		var err error
+		if order, err = h.orders.WithId(event.OrderId); err != nil {
+			return err
+		}
+	}
+
+	return fn(event, order)
+}
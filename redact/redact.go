@@ -0,0 +1,222 @@
+// Package redact masks the credentials and the banking data in the requests and the responses
+// of the API, before they are written to cassettes or logs.
+package redact
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// Redacted replaces the redacted values.
+const Redacted = "REDACTED"
+
+// DefaultKeys are the keys of the secrets and the account numbers in the bodies.
+var DefaultKeys = []string{
+	"access_token",
+	"refresh_token",
+	"client_assertion",
+	"signing_secret",
+	"account_no",
+	"iban",
+	"sort_code",
+	"routing_number",
+}
+
+// headers are the headers carrying credentials.
+var headers = []string{"Authorization", "Cookie", "Set-Cookie"}
+
+var (
+	bearerRegexp = regexp.MustCompile(`(?i)\bbearer\s+[A-Za-z0-9\-._~+/]+=*`)
+	emailRegexp  = regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`)
+	ibanRegexp   = regexp.MustCompile(`\b[A-Z]{2}[0-9]{2}(?: ?[A-Z0-9]){11,30}\b`)
+	cardRegexp   = regexp.MustCompile(`\b[0-9](?:[ \-]?[0-9]){12,18}\b`)
+)
+
+// Keys replaces the string values of the keys in a JSON or form body with Redacted,
+// other bodies are returned as they are.
+func Keys(body []byte, keys []string) []byte {
+	if len(keys) == 0 {
+		return body
+	}
+
+	return redactBody(body, keySet(keys), nil)
+}
+
+// Body masks the values of DefaultKeys and the bearer tokens, the e-mails, the IBANs and the
+// card numbers in the string values of a JSON or form body, or anywhere in another body.
+func Body(body []byte) []byte {
+	trimmed := bytes.TrimSpace(body)
+	if len(trimmed) == 0 {
+		return body
+	}
+
+	if isJson(trimmed) && json.Valid(trimmed) || isForm(trimmed) {
+		return redactBody(body, keySet(DefaultKeys), Text)
+	}
+
+	return []byte(Text(string(body)))
+}
+
+// Text masks the bearer tokens, the e-mails, the IBANs and the card numbers in s.
+func Text(s string) string {
+	s = bearerRegexp.ReplaceAllString(s, "Bearer "+Redacted)
+	s = emailRegexp.ReplaceAllString(s, Redacted)
+	s = ibanRegexp.ReplaceAllString(s, Redacted)
+
+	return cardRegexp.ReplaceAllStringFunc(s, func(match string) string {
+		if !luhn(match) {
+			return match
+		}
+		return Redacted
+	})
+}
+
+// Header returns a copy of the header with the credentials replaced by Redacted.
+func Header(header http.Header) http.Header {
+	c := http.Header{}
+	for k, v := range header {
+		c[k] = append([]string{}, v...)
+	}
+
+	for _, name := range headers {
+		if c.Get(name) != "" {
+			c.Set(name, Redacted)
+		}
+	}
+
+	return c
+}
+
+func keySet(keys []string) map[string]bool {
+	set := map[string]bool{}
+	for _, key := range keys {
+		set[key] = true
+	}
+	return set
+}
+
+func isJson(body []byte) bool {
+	return body[0] == '{' || body[0] == '['
+}
+
+func isForm(body []byte) bool {
+	if !bytes.Contains(body, []byte("=")) || bytes.ContainsAny(body, " \n\"{") {
+		return false
+	}
+	_, err := url.ParseQuery(string(body))
+	return err == nil
+}
+
+// redactBody replaces the values of the keys and passes the other string values through mask,
+// the body is returned as it is when nothing changed.
+func redactBody(body []byte, keys map[string]bool, mask func(string) string) []byte {
+	trimmed := bytes.TrimSpace(body)
+	if len(trimmed) == 0 {
+		return body
+	}
+
+	if isJson(trimmed) {
+		var v interface{}
+		d := json.NewDecoder(bytes.NewReader(trimmed))
+		d.UseNumber()
+		if err := d.Decode(&v); err != nil {
+			return body
+		}
+
+		v, changed := redactJson(v, "", keys, mask)
+		if !changed {
+			return body
+		}
+
+		b, err := json.Marshal(v)
+		if err != nil {
+			return body
+		}
+		return b
+	}
+
+	if !isForm(trimmed) {
+		return body
+	}
+
+	values, _ := url.ParseQuery(string(trimmed))
+	changed := false
+	for key := range values {
+		for i, value := range values[key] {
+			redacted := value
+			if keys[key] {
+				redacted = Redacted
+			} else if mask != nil {
+				redacted = mask(value)
+			}
+			if redacted != value {
+				values[key][i] = redacted
+				changed = true
+			}
+		}
+	}
+	if !changed {
+		return body
+	}
+
+	return []byte(values.Encode())
+}
+
+// redactJson returns the value with the redactions, and whether anything changed.
+func redactJson(v interface{}, key string, keys map[string]bool, mask func(string) string) (interface{}, bool) {
+	switch value := v.(type) {
+	case string:
+		if keys[key] {
+			return Redacted, value != Redacted
+		}
+		if mask != nil {
+			masked := mask(value)
+			return masked, masked != value
+		}
+	case map[string]interface{}:
+		changed := false
+		for k, item := range value {
+			redacted, c := redactJson(item, k, keys, mask)
+			if c {
+				value[k] = redacted
+				changed = true
+			}
+		}
+		return value, changed
+	case []interface{}:
+		changed := false
+		for i, item := range value {
+			redacted, c := redactJson(item, key, keys, mask)
+			if c {
+				value[i] = redacted
+				changed = true
+			}
+		}
+		return value, changed
+	}
+
+	return v, false
+}
+
+// luhn reports whether the digits of s pass the Luhn check of card numbers.
+func luhn(s string) bool {
+	digits := strings.NewReplacer(" ", "", "-", "").Replace(s)
+
+	sum := 0
+	for i := 0; i < len(digits); i++ {
+		d := int(digits[len(digits)-1-i] - '0')
+		if i%2 == 1 {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+	}
+
+	return sum%10 == 0
+}
@@ -0,0 +1,255 @@
+package webhook
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	SignatureHeader = "Revolut-Signature"
+	TimestampHeader = "Revolut-Request-Timestamp"
+
+	signatureVersion = "v1"
+
+	// DefaultTolerance is the largest accepted difference between the timestamp of an event and now.
+	DefaultTolerance = 5 * time.Minute
+	// DefaultMaxBodySize is the largest event body read by the middleware, 1 MB.
+	DefaultMaxBodySize = 1 << 20
+)
+
+var (
+	ErrMissingSignature        = errors.New("webhook: missing signature or timestamp")
+	ErrInvalidTimestamp        = errors.New("webhook: invalid timestamp")
+	ErrTimestampOutOfTolerance = errors.New("webhook: timestamp outside of the tolerance")
+	ErrInvalidSignature        = errors.New("webhook: invalid signature")
+	ErrBodyTooLarge            = errors.New("webhook: body too large")
+)
+
+// Sign returns the signature of the body in the format of the Revolut-Signature header.
+// timestamp is the value of the Revolut-Request-Timestamp header, in ms since the Unix epoch.
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	fmt.Fprintf(mac, "%s.%d.", signatureVersion, timestamp)
+	mac.Write(body)
+
+	return signatureVersion + "=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// SignRequest sets the signature headers of the request for the body.
+func SignRequest(req *http.Request, secret string, timestamp time.Time, body []byte) {
+	ms := timestamp.UnixNano() / int64(time.Millisecond)
+	req.Header.Set(TimestampHeader, strconv.FormatInt(ms, 10))
+	req.Header.Set(SignatureHeader, Sign(secret, ms, body))
+}
+
+// ReplayCache remembers the signatures of the accepted events.
+type ReplayCache interface {
+	// SeenOrAdd reports whether the signature is already remembered, otherwise it remembers it
+	// until expiresAt, both in one step so concurrent deliveries of an event are accepted once
+	SeenOrAdd(signature string, expiresAt time.Time) bool
+	// Remove forgets the signature, so the retry of an event which failed is handled again
+	Remove(signature string)
+}
+
+// MemoryReplayCache is a ReplayCache kept in memory.
+type MemoryReplayCache struct {
+	mu         sync.Mutex
+	signatures map[string]time.Time
+}
+
+func NewMemoryReplayCache() *MemoryReplayCache {
+	return &MemoryReplayCache{signatures: map[string]time.Time{}}
+}
+
+func (c *MemoryReplayCache) SeenOrAdd(signature string, expiresAt time.Time) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	for s, e := range c.signatures {
+		if !now.Before(e) {
+			delete(c.signatures, s)
+		}
+	}
+
+	if _, ok := c.signatures[signature]; ok {
+		return true
+	}
+	c.signatures[signature] = expiresAt
+
+	return false
+}
+
+func (c *MemoryReplayCache) Remove(signature string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.signatures, signature)
+}
+
+// Verifier checks the signatures of the web-hook events of both the Business and the Merchant API.
+// It accepts an event signed by any of its secrets, so the old and the new secret
+// can be active at the same time during a rotation.
+type Verifier struct {
+	mu      sync.RWMutex
+	secrets []string
+
+	// the largest accepted difference between the event timestamp and now, default is DefaultTolerance
+	Tolerance time.Duration
+	// an optional cache of the accepted signatures, events with a known signature are not handled again
+	ReplayCache ReplayCache
+	// the largest body read by the middleware, default is DefaultMaxBodySize
+	MaxBodySize int64
+}
+
+func NewVerifier(secrets ...string) *Verifier {
+	return &Verifier{
+		secrets:     secrets,
+		Tolerance:   DefaultTolerance,
+		MaxBodySize: DefaultMaxBodySize,
+	}
+}
+
+// SetSecrets replaces the active secrets.
+func (v *Verifier) SetSecrets(secrets ...string) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	v.secrets = secrets
+}
+
+// Verify checks the signature headers of the body and returns the matching signature.
+func (v *Verifier) Verify(header http.Header, body []byte) (string, error) {
+	signatures := header.Get(SignatureHeader)
+	timestamp := header.Get(TimestampHeader)
+	if signatures == "" || timestamp == "" {
+		return "", ErrMissingSignature
+	}
+
+	ms, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return "", ErrInvalidTimestamp
+	}
+
+	tolerance := v.Tolerance
+	if tolerance <= 0 {
+		tolerance = DefaultTolerance
+	}
+	diff := time.Since(time.Unix(0, ms*int64(time.Millisecond)))
+	if diff > tolerance || diff < -tolerance {
+		return "", ErrTimestampOutOfTolerance
+	}
+
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+
+	for _, secret := range v.secrets {
+		expected := Sign(secret, ms, body)
+		for _, signature := range strings.Split(signatures, ",") {
+			signature = strings.TrimSpace(signature)
+			if hmac.Equal([]byte(signature), []byte(expected)) {
+				return signature, nil
+			}
+		}
+	}
+
+	return "", ErrInvalidSignature
+}
+
+// Middleware verifies the events before passing them to next, which can be
+// the web-hook handler of either API. Events failing the verification are
+// answered with 401. Replayed events are answered with 200 without reaching next.
+func (v *Verifier) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := readBody(r, v.MaxBodySize)
+		if err == ErrBodyTooLarge {
+			http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		signature, err := v.Verify(r.Header, body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		if v.ReplayCache != nil {
+			tolerance := v.Tolerance
+			if tolerance <= 0 {
+				tolerance = DefaultTolerance
+			}
+			// a replay older than the tolerance fails on the timestamp anyway
+			if v.ReplayCache.SeenOrAdd(signature, time.Now().Add(2*tolerance)) {
+				w.WriteHeader(http.StatusOK)
+				return
+			}
+		}
+
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r)
+
+		if v.ReplayCache != nil && rec.status >= http.StatusMultipleChoices {
+			v.ReplayCache.Remove(signature)
+		}
+	})
+}
+
+// readBody reads the body of the request and puts it back for the next handler.
+func readBody(r *http.Request, maxBodySize int64) ([]byte, error) {
+	if maxBodySize <= 0 {
+		maxBodySize = DefaultMaxBodySize
+	}
+
+	body, err := ioutil.ReadAll(io.LimitReader(r.Body, maxBodySize+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(body)) > maxBodySize {
+		return nil, ErrBodyTooLarge
+	}
+	r.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	return body, nil
+}
+
+// statusRecorder keeps the status and the beginning of the body of a response
+type statusRecorder struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+	body        bytes.Buffer
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	if !r.wroteHeader {
+		r.status = status
+		r.wroteHeader = true
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *statusRecorder) Write(b []byte) (int, error) {
+	r.wroteHeader = true
+	if n := 512 - r.body.Len(); n > 0 {
+		if n > len(b) {
+			n = len(b)
+		}
+		r.body.Write(b[:n])
+	}
+	return r.ResponseWriter.Write(b)
+}
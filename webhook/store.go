@@ -0,0 +1,439 @@
+package webhook
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+type EventStatus string
+
+const (
+	EventStatus_RECEIVED  EventStatus = "received"
+	EventStatus_PROCESSED EventStatus = "processed"
+	EventStatus_FAILED    EventStatus = "failed"
+)
+
+type StoredEvent struct {
+	// the identity of the event, the same for every delivery of the event
+	Id string `json:"id"`
+	// the raw body of the event
+	Body string `json:"body"`
+	// the headers of the first delivery
+	Header http.Header `json:"header,omitempty"`
+	// the instant when the event was first received
+	ReceivedAt time.Time `json:"received_at"`
+	// the instant when the event was last processed
+	UpdatedAt time.Time `json:"updated_at"`
+	// the processing status of the event
+	Status EventStatus `json:"status"`
+	// the response of the last failed processing
+	Error string `json:"error,omitempty"`
+	// the number of times the event was processed
+	Attempts int `json:"attempts"`
+}
+
+var ErrEventNotFound = errors.New("webhook: event not found")
+
+// EventStore persists the received events.
+type EventStore interface {
+	// Save stores the event unless an event with the same ID exists.
+	// It returns the stored event and whether it was created.
+	Save(event *StoredEvent) (*StoredEvent, bool, error)
+	// Update replaces the stored event with the same ID
+	Update(event *StoredEvent) error
+	// Get returns the event with the ID, or ErrEventNotFound
+	Get(id string) (*StoredEvent, error)
+	// List returns the events with the status ordered by receiving, all events for an empty status
+	List(status EventStatus) ([]*StoredEvent, error)
+}
+
+// IdentityFunc returns the identity of an event body.
+type IdentityFunc func(body []byte) string
+
+// EventIdentity identifies the Business events by the event name, the transaction ID
+// and the new state, and the Merchant events by the event name and the order ID.
+// Other bodies are identified by their SHA-256 hash.
+func EventIdentity(body []byte) string {
+	var event struct {
+		Event   string `json:"event"`
+		OrderId string `json:"order_id"`
+		Data    struct {
+			Id       string `json:"id"`
+			NewState string `json:"new_state"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &event); err == nil && event.Event != "" {
+		if event.OrderId != "" {
+			return event.Event + "|" + event.OrderId
+		}
+		if event.Data.Id != "" {
+			return event.Event + "|" + event.Data.Id + "|" + event.Data.NewState
+		}
+	}
+
+	sum := sha256.Sum256(body)
+	return hex.EncodeToString(sum[:])
+}
+
+// Recorder stores every received event, skips the events which were already processed
+// and replays the stored events through a handler.
+type Recorder struct {
+	store EventStore
+
+	// the identity of the events, default is EventIdentity
+	Identity IdentityFunc
+	// the largest body read by the middleware, default is DefaultMaxBodySize
+	MaxBodySize int64
+
+	mu       sync.Mutex
+	inFlight map[string]bool
+}
+
+func NewRecorder(store EventStore) *Recorder {
+	return &Recorder{
+		store:       store,
+		Identity:    EventIdentity,
+		MaxBodySize: DefaultMaxBodySize,
+	}
+}
+
+// Middleware stores the event and passes it to next, which can be the web-hook handler
+// of either API. Events processed before or being processed by a concurrent delivery are answered
+// with 200 without reaching next. Put it behind Verifier.Middleware, so only verified events are stored.
+func (rec *Recorder) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := readBody(r, rec.MaxBodySize)
+		if err == ErrBodyTooLarge {
+			http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		id := rec.identity(body)
+		if !rec.reserve(id) {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		// a processed event stays skipped by its status, a failed one is processed again on its retry
+		defer rec.release(id)
+
+		event, _, err := rec.store.Save(&StoredEvent{
+			Id:         id,
+			Body:       string(body),
+			Header:     r.Header.Clone(),
+			ReceivedAt: time.Now(),
+			Status:     EventStatus_RECEIVED,
+		})
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		if event.Status == EventStatus_PROCESSED {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+
+		sr := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(sr, r)
+
+		// the response is already written, on error the event stays received and is processed again
+		rec.finish(event, sr.status, sr.body.String())
+	})
+}
+
+// Replay passes the stored events with the status to next again, e.g. the failed ones after a fix.
+// It returns the replayed events with their new status.
+func (rec *Recorder) Replay(next http.Handler, status EventStatus) ([]*StoredEvent, error) {
+	events, err := rec.store.List(status)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, event := range events {
+		if err := rec.replay(next, event); err != nil {
+			return events, err
+		}
+	}
+
+	return events, nil
+}
+
+// ReplayEvent passes the stored event with the ID to next again.
+func (rec *Recorder) ReplayEvent(next http.Handler, id string) (*StoredEvent, error) {
+	event, err := rec.store.Get(id)
+	if err != nil {
+		return nil, err
+	}
+
+	return event, rec.replay(next, event)
+}
+
+func (rec *Recorder) replay(next http.Handler, event *StoredEvent) error {
+	req, err := http.NewRequest(http.MethodPost, "/", bytes.NewReader([]byte(event.Body)))
+	if err != nil {
+		return err
+	}
+	if event.Header != nil {
+		req.Header = event.Header.Clone()
+	}
+
+	w := &replayWriter{header: http.Header{}}
+	sr := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+	next.ServeHTTP(sr, req)
+
+	return rec.finish(event, sr.status, sr.body.String())
+}
+
+func (rec *Recorder) finish(event *StoredEvent, status int, response string) error {
+	event.Attempts++
+	event.UpdatedAt = time.Now()
+	if status < http.StatusMultipleChoices {
+		event.Status = EventStatus_PROCESSED
+		event.Error = ""
+	} else {
+		event.Status = EventStatus_FAILED
+		event.Error = http.StatusText(status) + ": " + response
+	}
+
+	return rec.store.Update(event)
+}
+
+// reserve marks the event as being processed, it reports false when it already is.
+func (rec *Recorder) reserve(id string) bool {
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+
+	if rec.inFlight == nil {
+		rec.inFlight = map[string]bool{}
+	}
+	if rec.inFlight[id] {
+		return false
+	}
+	rec.inFlight[id] = true
+
+	return true
+}
+
+func (rec *Recorder) release(id string) {
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+
+	delete(rec.inFlight, id)
+}
+
+func (rec *Recorder) identity(body []byte) string {
+	if rec.Identity == nil {
+		return EventIdentity(body)
+	}
+	return rec.Identity(body)
+}
+
+type replayWriter struct {
+	header http.Header
+}
+
+func (w *replayWriter) Header() http.Header         { return w.header }
+func (w *replayWriter) Write(b []byte) (int, error) { return len(b), nil }
+func (w *replayWriter) WriteHeader(int)             {}
+
+// MemoryEventStore keeps the events in memory.
+type MemoryEventStore struct {
+	mu     sync.Mutex
+	events map[string]*StoredEvent
+}
+
+func NewMemoryEventStore() *MemoryEventStore {
+	return &MemoryEventStore{events: map[string]*StoredEvent{}}
+}
+
+func (s *MemoryEventStore) Save(event *StoredEvent) (*StoredEvent, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if stored, ok := s.events[event.Id]; ok {
+		e := *stored
+		return &e, false, nil
+	}
+	e := *event
+	s.events[event.Id] = &e
+
+	return event, true, nil
+}
+
+func (s *MemoryEventStore) Update(event *StoredEvent) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.events[event.Id]; !ok {
+		return ErrEventNotFound
+	}
+	e := *event
+	s.events[event.Id] = &e
+
+	return nil
+}
+
+func (s *MemoryEventStore) Get(id string) (*StoredEvent, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored, ok := s.events[id]
+	if !ok {
+		return nil, ErrEventNotFound
+	}
+	e := *stored
+
+	return &e, nil
+}
+
+func (s *MemoryEventStore) List(status EventStatus) ([]*StoredEvent, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var r []*StoredEvent
+	for _, stored := range s.events {
+		if status == "" || stored.Status == status {
+			e := *stored
+			r = append(r, &e)
+		}
+	}
+	sortEvents(r)
+
+	return r, nil
+}
+
+// FileEventStore keeps every event in a JSON file of its own in a directory.
+type FileEventStore struct {
+	mu  sync.Mutex
+	dir string
+}
+
+// NewFileEventStore creates the directory when it does not exist.
+func NewFileEventStore(dir string) (*FileEventStore, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+
+	return &FileEventStore{dir: dir}, nil
+}
+
+func (s *FileEventStore) Save(event *StoredEvent) (*StoredEvent, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored, err := s.read(s.filename(event.Id))
+	if err == nil {
+		return stored, false, nil
+	}
+	if err != ErrEventNotFound {
+		return nil, false, err
+	}
+
+	if err := s.write(event); err != nil {
+		return nil, false, err
+	}
+
+	return event, true, nil
+}
+
+func (s *FileEventStore) Update(event *StoredEvent) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := os.Stat(s.filename(event.Id)); err != nil {
+		return ErrEventNotFound
+	}
+
+	return s.write(event)
+}
+
+func (s *FileEventStore) Get(id string) (*StoredEvent, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.read(s.filename(id))
+}
+
+func (s *FileEventStore) List(status EventStatus) ([]*StoredEvent, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	filenames, err := filepath.Glob(filepath.Join(s.dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	var r []*StoredEvent
+	for _, filename := range filenames {
+		event, err := s.read(filename)
+		if err != nil {
+			return nil, err
+		}
+		if status == "" || event.Status == status {
+			r = append(r, event)
+		}
+	}
+	sortEvents(r)
+
+	return r, nil
+}
+
+func (s *FileEventStore) filename(id string) string {
+	sum := sha256.Sum256([]byte(id))
+	return filepath.Join(s.dir, hex.EncodeToString(sum[:])+".json")
+}
+
+func (s *FileEventStore) read(filename string) (*StoredEvent, error) {
+	b, err := ioutil.ReadFile(filename)
+	if os.IsNotExist(err) {
+		return nil, ErrEventNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	event := &StoredEvent{}
+	if err := json.Unmarshal(b, event); err != nil {
+		return nil, err
+	}
+
+	return event, nil
+}
+
+// write replaces the file of the event atomically
+func (s *FileEventStore) write(event *StoredEvent) error {
+	b, err := json.MarshalIndent(event, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	filename := s.filename(event.Id)
+	if err := ioutil.WriteFile(filename+".tmp", b, 0644); err != nil {
+		return err
+	}
+
+	return os.Rename(filename+".tmp", filename)
+}
+
+func sortEvents(events []*StoredEvent) {
+	sort.Slice(events, func(i, j int) bool {
+		if !events[i].ReceivedAt.Equal(events[j].ReceivedAt) {
+			return events[i].ReceivedAt.Before(events[j].ReceivedAt)
+		}
+		return events[i].Id < events[j].Id
+	})
+}